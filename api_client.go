@@ -3,6 +3,7 @@ package client
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"io/ioutil"
 	"net/http"
@@ -23,7 +24,9 @@ var (
 // The API client
 type ApiClient struct {
 	config             ApiClientConfig
-	apiSigner          ApiSigner
+	keys               keyRing
+	httpClient         *http.Client
+	skew               clockSkew
 	apiBaseUrl         string
 	dphApplicationName string
 }
@@ -47,12 +50,18 @@ func (client *ApiClient) Init(config ApiClientConfig) error {
 	if os.Getenv("DHP_CLIENT_DEBUG") == "true" {
 		client.config.Debug = true
 	}
-	client.apiSigner.Init(config.SigningKey, config.SigningSecret, config.Debug)
+	if err := client.keys.init(client.config); err != nil {
+		return err
+	}
 	client.dphApplicationName = config.DhpApplicationName
+	client.httpClient = newHTTPClient(config)
 	return nil
 }
 
-func (client *ApiClient) sendRestRequest(httpMethod string, uri *url.URL, header *http.Header, body []byte) Response {
+// doRequest performs a single round trip to DHP and parses the response code.
+// Transport failures are returned as is. When DHP answers with an error
+// the parsed Response is returned together with a *DHPError
+func (client *ApiClient) doRequest(ctx context.Context, httpMethod string, uri *url.URL, header *http.Header, body []byte) (*Response, error) {
 	if header.Get("Content-Type") == "" {
 		header.Set("Content-Type", "application/json")
 	}
@@ -61,15 +70,13 @@ func (client *ApiClient) sendRestRequest(httpMethod string, uri *url.URL, header
 	}
 
 	buf := bytes.NewBuffer(body)
-	req, err := http.NewRequest(httpMethod, uri.String(), buf)
+	req, err := http.NewRequestWithContext(ctx, httpMethod, uri.String(), buf)
 	if err != nil {
 		log.Error("Request failed: ", err.Error())
-		return Response{
-			Body: "error",
-		}
+		return nil, err
 	}
-	for k, _ := range *header {
-		req.Header.Set(k, header.Get(k))
+	for k := range *header {
+		req.Header[k] = append([]string(nil), (*header)[k]...)
 	}
 
 	if client.config.Debug {
@@ -78,29 +85,33 @@ func (client *ApiClient) sendRestRequest(httpMethod string, uri *url.URL, header
 	}
 
 	// Fetch Request
-	c := &http.Client{}
-	resp, err := c.Do(req)
+	resp, err := client.httpClient.Do(req)
 	if err != nil {
 		log.Error("Request failed: ", err)
+		return nil, err
 	}
 
-	if client.config.Debug && resp != nil {
+	if client.config.Debug {
 		dumped, _ := httputil.DumpResponse(resp, false)
 		log.Info(string(dumped))
 	}
+	if !client.config.IgnoreClockSkew {
+		client.skew.learn(resp)
+	}
 
+	// Read Response Body. Closing it hands the connection back to the pool
+	responseBody, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
 	if err != nil {
-		return Response{
-			Body:       err.Error(),
-			StatusCode: 500,
-			DhpCode:    0,
-			Response:   resp,
-		}
-
+		log.Error("Reading response failed: ", err)
+		return nil, err
+	}
+	response := &Response{
+		Body:       string(responseBody),
+		StatusCode: resp.StatusCode,
+		DhpCode:    0,
+		Response:   resp,
 	}
-
-	// Read Response Body
-	responseBody, _ := ioutil.ReadAll(resp.Body)
 
 	jsonParsed, err := gabs.ParseJSON([]byte(responseBody))
 	if err == nil {
@@ -109,40 +120,140 @@ func (client *ApiClient) sendRestRequest(httpMethod string, uri *url.URL, header
 			if client.config.Debug {
 				log.Info("Found responseCode: ", dhpCode)
 			}
-			intCode, _ := strconv.Atoi(dhpCode)
-			return Response{
-				Body:       string(responseBody),
-				StatusCode: resp.StatusCode,
-				DhpCode:    intCode,
-				Response:   resp,
-			}
+			response.DhpCode, _ = strconv.Atoi(dhpCode)
 		} else {
 			log.Error("Response code not found")
 		}
+		response.Errors = parseFieldErrors(jsonParsed)
+	} else if client.config.Debug {
+		log.Info("Returning RAW response")
+	}
+	if isErrorResponse(response) {
+		return response, newDHPError(response)
+	}
+	return response, nil
+}
 
+// send signs the request when asked to and sends it, retrying transient
+// failures according to the configured RetryPolicy. Every attempt starts
+// from the headers as passed in and gets a fresh SignedDate, a SignedDate
+// passed in is only used for the first try. A request
+// rejected with 3056 Signature expired is signed again and retried once,
+// using the clock skew learned from the response. When DHP rejects the
+// signing key the fallback keys are tried in turn. Signed requests fail
+// without a round trip when the credentials provider fails
+func (client *ApiClient) send(ctx context.Context, httpMethod, apiEndpoint, queryParams string, header *http.Header, body []byte, signed bool) (*Response, error) {
+	policy := client.retryPolicy(ctx)
+	original := header.Clone()
+	uri := client.createUri(apiEndpoint, queryParams)
+	resigned := !signed
+	keysTried := 1
+	for attempt, try := 1, 1; ; attempt, try = attempt+1, try+1 {
+		*header = original.Clone()
+		if try > 1 {
+			// A SignedDate passed in is only used for the first try
+			header.Del("SignedDate")
+		}
+		keyIndex, signer, err := client.keys.signer()
+		if signed && err != nil {
+			log.Error("Fetching credentials failed: ", err)
+			return nil, err
+		}
+		if signed {
+			client.sign(signer, client.signingTime(), header, uri.Path, queryParams, httpMethod, body)
+		}
+		response, err := client.doRequest(ctx, httpMethod, uri, header, body)
+		if signed && keysTried < client.keys.size() && client.keys.isKeyRejected(err) {
+			keysTried++
+			client.keys.rotate(keyIndex)
+			if client.config.Debug {
+				log.Info("Signing key rejected, trying the next one: ", err)
+			}
+			attempt--
+			continue
+		}
+		if !resigned && errors.Is(err, ErrSignatureExpired) {
+			resigned = true
+			if client.config.Debug {
+				log.Info("Signature expired, signing again with clock skew ", client.ClockSkew())
+			}
+			attempt--
+			continue
+		}
+		if attempt >= policy.maxAttempts() || !policy.retryable(ctx, httpMethod, response, err) {
+			return response, err
+		}
+		wait := policy.backoff(attempt, response)
+		if client.config.Debug {
+			log.Info("Retrying after ", wait, ": ", err)
+		}
+		if sleep(ctx, wait) != nil {
+			return response, err
+		}
 	}
-	if client.config.Debug {
-		log.Info("Returning RAW response")
+}
+
+// signingTime returns the time to stamp SignedDate with
+func (client *ApiClient) signingTime() time.Time {
+	if client.config.IgnoreClockSkew {
+		return time.Now().UTC()
+	}
+	return client.skew.now()
+}
+
+// ClockSkew returns the estimated offset of the DHP clock relative to
+// the local one, as learned from the Date header of earlier responses
+func (client *ApiClient) ClockSkew() time.Duration {
+	return client.skew.Offset()
+}
+
+// legacyResponse keeps the historic behaviour of always returning a Response:
+// failures to build the request yield a Body of "error" and transport
+// failures are reported as StatusCode 500 with the error text in the Body
+func legacyResponse(response *Response, err error) Response {
+	if response != nil {
+		return *response
+	}
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return Response{
+			Body:       err.Error(),
+			StatusCode: 500,
+			DhpCode:    0,
+		}
 	}
 	return Response{
-		Body:       string(responseBody),
-		StatusCode: resp.StatusCode,
-		DhpCode:    0,
-		Response:   resp,
+		Body: "error",
 	}
 }
 
-func (client *ApiClient) sendSignedRequest(httpMethod, apiEndpoint, queryParams string, header *http.Header, body []byte) Response {
-	now := time.Now().UTC()
-	client.Sign(now, header, apiEndpoint, queryParams, httpMethod, body)
-	uri := client.createUri(apiEndpoint, queryParams)
+func (client *ApiClient) sendSignedRequest(ctx context.Context, httpMethod, apiEndpoint, queryParams string, header *http.Header, body []byte) Response {
+	return legacyResponse(client.send(ctx, httpMethod, apiEndpoint, queryParams, header, body, true))
+}
+
+// Do sends a signed request to the configured service. Network failures are
+// returned as is. When DHP answers with an error status or response code the
+// parsed Response is returned together with a *DHPError, which can be matched
+// using errors.Is against ErrAccessTokenExpired and friends.
+// Segments of apiEndpoint may be escaped with url.PathEscape to keep a / or ?
+// inside them, the signature covers the unescaped path
+func (client *ApiClient) Do(ctx context.Context, httpMethod, apiEndpoint, queryParams string, header *http.Header, body []byte) (*Response, error) {
+	return client.send(ctx, httpMethod, apiEndpoint, queryParams, header, body, true)
+}
 
-	return client.sendRestRequest(httpMethod, uri, header, body)
+// DoRest is like Do but sends the request without signing it
+func (client *ApiClient) DoRest(ctx context.Context, httpMethod, apiEndpoint, queryParams string, header *http.Header, body []byte) (*Response, error) {
+	return client.send(ctx, httpMethod, apiEndpoint, queryParams, header, body, false)
 }
 
 func (client *ApiClient) SendRestRequest(httpMethod, apiEndpoint, queryParams string, header *http.Header, body []byte) Response {
-	uri := client.createUri(apiEndpoint, queryParams)
-	return client.sendRestRequest(httpMethod, uri, header, body)
+	return client.SendRestRequestWithContext(context.Background(), httpMethod, apiEndpoint, queryParams, header, body)
+}
+
+// SendRestRequestWithContext sends an unsigned request to the configured service.
+// The request is aborted when ctx is cancelled or its deadline expires
+func (client *ApiClient) SendRestRequestWithContext(ctx context.Context, httpMethod, apiEndpoint, queryParams string, header *http.Header, body []byte) Response {
+	return legacyResponse(client.send(ctx, httpMethod, apiEndpoint, queryParams, header, body, false))
 }
 
 func (client *ApiClient) DHPApplicationName() string {
@@ -153,23 +264,52 @@ func (client *ApiClient) DHPApplicationName() string {
 // It returns a Response struct which contains the parsed response code
 // from the service. A full copy of the body is also returned
 func (client *ApiClient) SendSignedRequest(httpMethod, apiEndpoint, queryParams string, header *http.Header, body []byte) Response {
+	return client.SendSignedRequestWithContext(context.Background(), httpMethod, apiEndpoint, queryParams, header, body)
+}
 
-	return client.sendSignedRequest(httpMethod, apiEndpoint, queryParams, header, body)
+// SendSignedRequestWithContext is like SendSignedRequest but the request
+// is aborted when ctx is cancelled or its deadline expires
+func (client *ApiClient) SendSignedRequestWithContext(ctx context.Context, httpMethod, apiEndpoint, queryParams string, header *http.Header, body []byte) Response {
+	return client.sendSignedRequest(ctx, httpMethod, apiEndpoint, queryParams, header, body)
 }
 
 func (client *ApiClient) Sign(now time.Time, header *http.Header, url, queryParams, httpMethod string, body []byte) {
+	_, signer, err := client.keys.signer()
+	if err != nil {
+		log.Error("Fetching credentials failed, using the last known ones: ", err)
+	}
+	client.sign(signer, now, header, url, queryParams, httpMethod, body)
+}
+
+// SignWithTrace is like Sign but also returns a SigningTrace explaining
+// how the Authorization header was computed
+func (client *ApiClient) SignWithTrace(now time.Time, header *http.Header, url, queryParams, httpMethod string, body []byte) *SigningTrace {
+	_, signer, err := client.keys.signer()
+	if err != nil {
+		log.Error("Fetching credentials failed, using the last known ones: ", err)
+	}
+	return client.sign(signer, now, header, url, queryParams, httpMethod, body)
+}
+
+func (client *ApiClient) sign(signer *ApiSigner, now time.Time, header *http.Header, url, queryParams, httpMethod string, body []byte) *SigningTrace {
 	if header.Get("SignedDate") == "" {
 		header.Set("SignedDate", now.Format(TIME_FORMAT))
 	}
-	authHeaderValue := client.apiSigner.BuildAuthorizationHeaderValue(httpMethod, queryParams, header, url, body)
+	authHeaderValue, trace := signer.BuildAuthorizationHeaderValueWithTrace(httpMethod, queryParams, header, url, body)
+	if client.config.Debug {
+		log.Info(trace.String())
+	}
 	header.Set("Authorization", authHeaderValue)
+	return trace
 }
 
 func (client *ApiClient) createUri(apiEndpoint, queryParams string) *url.URL {
 	log.Debug("BaseUrl: %s", client.apiBaseUrl)
-	url, _ := url.Parse(client.apiBaseUrl)
-	url.Parse(client.apiBaseUrl)
-	url.Path = apiEndpoint
-	url.RawQuery = queryParams
-	return url
+	uri, _ := url.Parse(client.apiBaseUrl)
+	uri.Path = apiEndpoint
+	if path, err := url.PathUnescape(apiEndpoint); err == nil && path != apiEndpoint {
+		uri.Path, uri.RawPath = path, apiEndpoint
+	}
+	uri.RawQuery = queryParams
+	return uri
 }
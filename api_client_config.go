@@ -1,5 +1,9 @@
 package client
 
+import (
+	"net/http"
+)
+
 type ApiClientConfig struct {
 	ApiBaseUrl         string
 	DhpApplicationName string
@@ -7,6 +11,15 @@ type ApiClientConfig struct {
 	SigningSecret      string
 	PropositionName    string
 	Debug              bool
+	HTTPClient         *http.Client        // Optional client to use, takes precedence over Transport
+	Transport          http.RoundTripper   // Optional transport, defaults to the pooled DefaultTransport
+	Retry              *RetryPolicy        // Optional retry policy, nil means a single attempt
+	IgnoreClockSkew    bool                // Stamp SignedDate from the local clock only
+	SignedHeaders      []string            // Optional headers to sign, by default all headers are signed
+	SigningAlgorithm   string              // Optional signing algorithm, defaults to ALGORITHM_NAME
+	FallbackKeys       []SigningCredential // Tried in turn when DHP rejects SigningKey with one of KeyRejectedCodes
+	KeyRejectedCodes   []int               // DHP codes moving on to the next key, defaults to DefaultKeyRejectedCodes
+	Credentials        CredentialsProvider // Optional, consulted per request instead of SigningKey and SigningSecret
 }
 
 func (config *ApiClientConfig) Init(apiBaseUrl, dhpApplicationName, signingKey, signingSecret, propositionName string, debug bool) {
@@ -0,0 +1,49 @@
+package client
+
+import (
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// clockSkew keeps the estimated offset between the DHP clock and the local one.
+// DHP rejects requests whose SignedDate is too far off with 3056, so the
+// offset is applied to every SignedDate the client generates
+type clockSkew struct {
+	offset int64 // time.Duration, accessed atomically
+}
+
+// Skews below the resolution of the Date header are treated as no skew at all
+const clockSkewResolution = time.Second
+
+func (skew *clockSkew) Offset() time.Duration {
+	return time.Duration(atomic.LoadInt64(&skew.offset))
+}
+
+// now returns the local time corrected by the estimated offset
+func (skew *clockSkew) now() time.Time {
+	return time.Now().Add(skew.Offset()).UTC()
+}
+
+// learn updates the offset from the Date header of a DHP response. Responses
+// served from a cache, which carry an Age header, and gateway errors, which
+// usually come from a proxy in between, do not tell the DHP clock
+func (skew *clockSkew) learn(resp *http.Response) {
+	if resp == nil || resp.Header.Get("Age") != "" {
+		return
+	}
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return
+	}
+	serverDate, err := http.ParseTime(resp.Header.Get("Date"))
+	if err != nil {
+		return
+	}
+	// The Date header is truncated to the second, so aim for the middle
+	offset := serverDate.Add(clockSkewResolution / 2).Sub(time.Now())
+	if offset > -clockSkewResolution && offset < clockSkewResolution {
+		offset = 0
+	}
+	atomic.StoreInt64(&skew.offset, int64(offset))
+}
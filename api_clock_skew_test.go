@@ -0,0 +1,45 @@
+package client
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func skewedResponse(status int, offset time.Duration) *http.Response {
+	header := http.Header{}
+	header.Set("Date", time.Now().Add(offset).UTC().Format(http.TimeFormat))
+	return &http.Response{StatusCode: status, Header: header}
+}
+
+func TestClockSkewLearnsFromDHP(t *testing.T) {
+	skew := &clockSkew{}
+	skew.learn(skewedResponse(http.StatusOK, time.Hour))
+	if offset := skew.Offset(); offset < 59*time.Minute || offset > 61*time.Minute {
+		t.Errorf("expected an offset of about an hour, got %v", offset)
+	}
+	skew.learn(skewedResponse(http.StatusOK, 0))
+	if offset := skew.Offset(); offset != 0 {
+		t.Errorf("expected the offset to be reset, got %v", offset)
+	}
+}
+
+func TestClockSkewIgnoresCachedResponses(t *testing.T) {
+	skew := &clockSkew{}
+	cached := skewedResponse(http.StatusOK, -time.Hour)
+	cached.Header.Set("Age", "3600")
+	skew.learn(cached)
+	if offset := skew.Offset(); offset != 0 {
+		t.Errorf("expected a cached response to be ignored, got %v", offset)
+	}
+}
+
+func TestClockSkewIgnoresGatewayErrors(t *testing.T) {
+	skew := &clockSkew{}
+	for _, status := range []int{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout} {
+		skew.learn(skewedResponse(status, time.Hour))
+	}
+	if offset := skew.Offset(); offset != 0 {
+		t.Errorf("expected gateway errors to be ignored, got %v", offset)
+	}
+}
@@ -0,0 +1,51 @@
+package client
+
+import (
+	"net"
+	"net/http"
+	"time"
+)
+
+var (
+	// DefaultTimeout is the overall timeout applied to requests sent with
+	// the default http.Client. It includes connecting, redirects and reading the body
+	DefaultTimeout = 60 * time.Second
+
+	// DefaultTransport is the pooled transport shared by all clients which are
+	// not configured with their own HTTPClient or Transport. Sharing it keeps
+	// keep-alive connections to DHP around between calls
+	DefaultTransport http.RoundTripper = newDefaultTransport()
+)
+
+func newDefaultTransport() *http.Transport {
+	return &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   20,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ResponseHeaderTimeout: 30 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+}
+
+// newHTTPClient picks the http.Client for a config. An explicit HTTPClient
+// wins, then a custom Transport, and finally the shared DefaultTransport
+func newHTTPClient(config ApiClientConfig) *http.Client {
+	if config.HTTPClient != nil {
+		return config.HTTPClient
+	}
+	transport := config.Transport
+	if transport == nil {
+		transport = DefaultTransport
+	}
+	return &http.Client{
+		Transport: transport,
+		Timeout:   DefaultTimeout,
+	}
+}
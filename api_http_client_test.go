@@ -0,0 +1,83 @@
+package client
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+// newCountingServer returns a DHP stand-in answering 200 which counts
+// the TCP connections opened to it
+func newCountingServer() (*httptest.Server, *int64) {
+	var conns int64
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"responseCode":"200"}`))
+	}))
+	srv.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt64(&conns, 1)
+		}
+	}
+	srv.Start()
+	return srv, &conns
+}
+
+func benchmarkRequests(b *testing.B, config ApiClientConfig, conns *int64) {
+	client, err := NewClient(config)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := client.Do(context.Background(), "GET", "/authentication/users/u/tokenStatus", "", &http.Header{}, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(atomic.LoadInt64(conns))/float64(b.N), "conns/op")
+}
+
+// BenchmarkPooledTransport sends requests over the shared DefaultTransport,
+// which keeps connections alive between calls
+func BenchmarkPooledTransport(b *testing.B) {
+	srv, conns := newCountingServer()
+	defer srv.Close()
+	benchmarkRequests(b, ApiClientConfig{ApiBaseUrl: srv.URL, SigningKey: "key", SigningSecret: "secret"}, conns)
+}
+
+// BenchmarkUnpooledTransport mimics the old behaviour of a fresh
+// connection for every request
+func BenchmarkUnpooledTransport(b *testing.B) {
+	srv, conns := newCountingServer()
+	defer srv.Close()
+	benchmarkRequests(b, ApiClientConfig{
+		ApiBaseUrl:    srv.URL,
+		SigningKey:    "key",
+		SigningSecret: "secret",
+		Transport:     &http.Transport{DisableKeepAlives: true},
+	}, conns)
+}
+
+func TestDefaultTransportReusesConnections(t *testing.T) {
+	srv, conns := newCountingServer()
+	defer srv.Close()
+	client, _ := NewClient(ApiClientConfig{ApiBaseUrl: srv.URL, SigningKey: "key", SigningSecret: "secret"})
+	for i := 0; i < 10; i++ {
+		if _, err := client.Do(context.Background(), "GET", "/x", "", &http.Header{}, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := atomic.LoadInt64(conns); n != 1 {
+		t.Errorf("expected 1 connection for 10 requests, got %d", n)
+	}
+}
+
+func TestHTTPClientTakesPrecedence(t *testing.T) {
+	httpClient := &http.Client{}
+	client, _ := NewClient(ApiClientConfig{ApiBaseUrl: "http://dhp", HTTPClient: httpClient, Transport: &http.Transport{}})
+	if client.httpClient != httpClient {
+		t.Error("HTTPClient not used")
+	}
+}
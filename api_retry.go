@@ -0,0 +1,153 @@
+package client
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy controls how transient DHP failures are retried.
+// Signed requests are signed again with a fresh SignedDate for every
+// attempt so retries do not run into 3056 Signature expired. Only
+// idempotent requests are retried unless RetryNonIdempotent is set
+type RetryPolicy struct {
+	MaxAttempts          int           // Total number of attempts, including the first one
+	InitialBackoff       time.Duration // Wait before the first retry, doubled for every next one
+	MaxBackoff           time.Duration // Upper bound of the wait between attempts
+	Jitter               float64       // Fraction of the wait which is randomized, between 0 and 1
+	RetryableStatusCodes []int         // HTTP status codes worth retrying
+	RetryableDhpCodes    []int         // DHP response codes worth retrying
+	RetryNetworkErrors   bool          // Also retry when no response was received at all
+	RetryNonIdempotent   bool          // Also retry POST and PATCH, which DHP may then perform twice
+}
+
+// DefaultRetryPolicy returns a policy retrying gateway errors and the DHP
+// codes which are known to be transient, up to three attempts in total
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:          3,
+		InitialBackoff:       250 * time.Millisecond,
+		MaxBackoff:           5 * time.Second,
+		Jitter:               0.5,
+		RetryableStatusCodes: []int{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout},
+		RetryableDhpCodes:    []int{1011, 1139, 1149, 1150},
+		RetryNetworkErrors:   true,
+	}
+}
+
+type retryPolicyContextKey struct{}
+
+// WithRetryPolicy returns a context making the requests sent with it use
+// policy instead of the Retry policy of the client. A nil policy sends
+// every request once
+func WithRetryPolicy(ctx context.Context, policy *RetryPolicy) context.Context {
+	return context.WithValue(ctx, retryPolicyContextKey{}, policy)
+}
+
+// retryPolicy returns the policy for requests sent with ctx
+func (client *ApiClient) retryPolicy(ctx context.Context) *RetryPolicy {
+	if policy, ok := ctx.Value(retryPolicyContextKey{}).(*RetryPolicy); ok {
+		return policy
+	}
+	return client.config.Retry
+}
+
+func (policy *RetryPolicy) maxAttempts() int {
+	if policy == nil || policy.MaxAttempts < 1 {
+		return 1
+	}
+	return policy.MaxAttempts
+}
+
+// retryable reports whether the outcome of an attempt is worth another try
+func (policy *RetryPolicy) retryable(ctx context.Context, method string, response *Response, err error) bool {
+	if policy == nil || err == nil || ctx.Err() != nil {
+		return false
+	}
+	if !policy.RetryNonIdempotent && !isIdempotent(method) {
+		return false
+	}
+	if response == nil {
+		return policy.RetryNetworkErrors
+	}
+	for _, code := range policy.RetryableStatusCodes {
+		if response.StatusCode == code {
+			return true
+		}
+	}
+	for _, code := range policy.RetryableDhpCodes {
+		if response.DhpCode == code {
+			return true
+		}
+	}
+	return false
+}
+
+// isIdempotent reports whether sending a request twice has the same
+// effect as sending it once
+func isIdempotent(method string) bool {
+	switch method {
+	case "GET", "HEAD", "OPTIONS", "TRACE", "PUT", "DELETE":
+		return true
+	}
+	return false
+}
+
+// backoff returns the wait before the next attempt. A Retry-After header
+// sent by DHP takes precedence over the exponential backoff, but is
+// capped by MaxBackoff as well
+func (policy *RetryPolicy) backoff(attempt int, response *Response) time.Duration {
+	if response != nil && response.Response != nil {
+		if wait, ok := retryAfter(response.Response.Header.Get("Retry-After")); ok {
+			if policy.MaxBackoff > 0 && wait > policy.MaxBackoff {
+				wait = policy.MaxBackoff
+			}
+			return wait
+		}
+	}
+	wait := policy.InitialBackoff
+	for i := 1; i < attempt && (policy.MaxBackoff <= 0 || wait < policy.MaxBackoff); i++ {
+		wait *= 2
+	}
+	if policy.MaxBackoff > 0 && wait > policy.MaxBackoff {
+		wait = policy.MaxBackoff
+	}
+	if policy.Jitter > 0 && wait > 0 {
+		jitter := time.Duration(policy.Jitter * float64(wait))
+		wait = wait - jitter + time.Duration(rand.Int63n(int64(jitter)+1))
+	}
+	return wait
+}
+
+// retryAfter parses a Retry-After header value, which is either
+// a number of seconds or an HTTP date
+func retryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		wait := time.Until(date)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+	return 0, false
+}
+
+// sleep waits for d to pass or ctx to be done, whichever comes first
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,125 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newFlakyServer answers 503 to the first failures requests and 200 after
+// that. It records the SignedDate of every request it receives
+func newFlakyServer(failures int) (*httptest.Server, func() []string) {
+	var mu sync.Mutex
+	var dates []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		dates = append(dates, r.Header.Get("SignedDate"))
+		n := len(dates)
+		mu.Unlock()
+		if n <= failures {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"responseCode":"200"}`))
+	}))
+	return srv, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), dates...)
+	}
+}
+
+func fastRetryPolicy() *RetryPolicy {
+	policy := DefaultRetryPolicy()
+	policy.InitialBackoff = time.Millisecond
+	policy.MaxBackoff = 2 * time.Millisecond
+	return policy
+}
+
+func TestRetryIdempotentRequests(t *testing.T) {
+	srv, dates := newFlakyServer(2)
+	defer srv.Close()
+	client, _ := NewClient(ApiClientConfig{ApiBaseUrl: srv.URL, SigningKey: "key", SigningSecret: "secret", Retry: fastRetryPolicy()})
+	if _, err := client.Do(context.Background(), "GET", "/x", "", &http.Header{}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(dates()); n != 3 {
+		t.Errorf("expected 3 attempts, got %d", n)
+	}
+}
+
+func TestWithRetryPolicy(t *testing.T) {
+	srv, dates := newFlakyServer(2)
+	defer srv.Close()
+	client, _ := NewClient(ApiClientConfig{ApiBaseUrl: srv.URL, SigningKey: "key", SigningSecret: "secret", Retry: fastRetryPolicy()})
+	if _, err := client.Do(WithRetryPolicy(context.Background(), nil), "GET", "/x", "", &http.Header{}, nil); err == nil {
+		t.Fatal("expected the 503 to be returned")
+	}
+	if n := len(dates()); n != 1 {
+		t.Errorf("expected a single attempt, got %d", n)
+	}
+}
+
+func TestNoRetryForPostByDefault(t *testing.T) {
+	srv, dates := newFlakyServer(1)
+	defer srv.Close()
+	client, _ := NewClient(ApiClientConfig{ApiBaseUrl: srv.URL, SigningKey: "key", SigningSecret: "secret", Retry: fastRetryPolicy()})
+	if _, err := client.Do(context.Background(), "POST", "/authentication/login", "", &http.Header{}, []byte(`{}`)); err == nil {
+		t.Fatal("expected the 503 to be returned")
+	}
+	if n := len(dates()); n != 1 {
+		t.Errorf("expected 1 attempt, got %d", n)
+	}
+
+	policy := fastRetryPolicy()
+	policy.RetryNonIdempotent = true
+	srv2, dates2 := newFlakyServer(1)
+	defer srv2.Close()
+	client, _ = NewClient(ApiClientConfig{ApiBaseUrl: srv2.URL, SigningKey: "key", SigningSecret: "secret", Retry: policy})
+	if _, err := client.Do(context.Background(), "POST", "/authentication/login", "", &http.Header{}, []byte(`{}`)); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(dates2()); n != 2 {
+		t.Errorf("expected 2 attempts with RetryNonIdempotent, got %d", n)
+	}
+}
+
+func TestRetryUsesFreshSignedDate(t *testing.T) {
+	srv, dates := newFlakyServer(1)
+	defer srv.Close()
+	client, _ := NewClient(ApiClientConfig{ApiBaseUrl: srv.URL, SigningKey: "key", SigningSecret: "secret", Retry: fastRetryPolicy()})
+	pinned := "2001-01-01T00:00:00.000+0000"
+	header := &http.Header{}
+	header.Set("SignedDate", pinned)
+	if _, err := client.Do(context.Background(), "GET", "/x", "", header, nil); err != nil {
+		t.Fatal(err)
+	}
+	got := dates()
+	if len(got) != 2 || got[0] != pinned || got[1] == pinned || got[1] == "" {
+		t.Errorf("expected the pinned date only on the first try, got %v", got)
+	}
+}
+
+func TestRetryAfterCappedByMaxBackoff(t *testing.T) {
+	policy := &RetryPolicy{InitialBackoff: time.Second, MaxBackoff: 2 * time.Second}
+	response := &Response{Response: &http.Response{Header: http.Header{"Retry-After": {"3600"}}}}
+	if wait := policy.backoff(1, response); wait != 2*time.Second {
+		t.Errorf("expected Retry-After capped at 2s, got %v", wait)
+	}
+	response.Response.Header.Set("Retry-After", "1")
+	if wait := policy.backoff(1, response); wait != time.Second {
+		t.Errorf("expected Retry-After of 1s, got %v", wait)
+	}
+}
+
+func TestBackoffGrowsUpToMax(t *testing.T) {
+	policy := &RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: 300 * time.Millisecond}
+	for attempt, expected := range []time.Duration{100, 200, 300, 300} {
+		if wait := policy.backoff(attempt+1, nil); wait != expected*time.Millisecond {
+			t.Errorf("attempt %d: expected %v, got %v", attempt+1, expected*time.Millisecond, wait)
+		}
+	}
+}
@@ -1,25 +1,32 @@
 package client
 
 import (
-	"bytes"
-	"crypto/hmac"
-	"crypto/sha256"
 	"encoding/base64"
 	"fmt"
 	"net/http"
 	"sort"
 	"strings"
+
+	log "github.com/sirupsen/logrus"
 )
 
 const (
-	ALGORITHM_NAME    = "HmacSHA256"
+	ALGORITHM_NAME    = ALGORITHM_HMAC_SHA256
 	SECRET_KEY_PREFIX = "DHPWS"
 )
 
+// dhpHeaderNames maps Go canonical header names to the
+// casing DHP uses for them in the string to sign
+var dhpHeaderNames = map[string]string{
+	"Signeddate": "SignedDate",
+}
+
 type ApiSigner struct {
-	secretKey string
-	sharedKey string
-	debug     bool
+	secretKey     string
+	sharedKey     string
+	debug         bool
+	signedHeaders []string
+	algorithm     string
 }
 
 func (signer *ApiSigner) Init(sharedKey, secretKey string, debug bool) {
@@ -28,87 +35,146 @@ func (signer *ApiSigner) Init(sharedKey, secretKey string, debug bool) {
 	signer.debug = debug
 }
 
-func (signer *ApiSigner) BuildAuthorizationHeaderValue(requestMethod, queryString string, header *http.Header, url string, body []byte) string {
-	joinedHeaders := joinHeaders(header)
-	signatureKey := signer.hashRequest(requestMethod, queryString, body, joinedHeaders)
-	signature := signString(signatureKey, url)
-
-	return signer.buildAuthorizationHeaderValue(joinedHeaders, signature)
-}
-
-func (signer *ApiSigner) buildAuthorizationHeaderValue(requestHeader, signature string) string {
-	buffer := bytes.NewBufferString(ALGORITHM_NAME)
-	buffer.WriteString(";")
-	buffer.WriteString("Credential:")
-	buffer.WriteString(signer.sharedKey)
-	buffer.WriteString(";")
-	buffer.WriteString("SignedHeaders:")
-	headers := strings.Split(requestHeader, ";")
-	for i, v := range headers {
-		headerName := strings.Split(v, ":")[0]
-		if i > 0 && len(headerName) > 0 {
-			buffer.WriteString(",")
-		}
-		buffer.WriteString(headerName)
-	}
-	buffer.WriteString(";")
-	buffer.WriteString("Signature:")
-	buffer.WriteString(signature)
-	return buffer.String()
+// SignHeaders restricts signing to the named headers, plus SignedDate which
+// is always signed. Headers added later on, e.g. by proxies, then do not
+// break the signature. Without names all headers present are signed
+func (signer *ApiSigner) SignHeaders(names ...string) {
+	signer.signedHeaders = names
 }
 
-func (signer *ApiSigner) hashRequest(requestMethod, queryString string, body []byte, requestHeaders string) []byte {
-	kSecret := []byte(SECRET_KEY_PREFIX + signer.secretKey)
-	if signer.debug {
-		fmt.Printf("kSecret = %v\n", kSecret)
+// SetAlgorithm selects the signing algorithm, one of SigningAlgorithms().
+// Signers use ALGORITHM_NAME unless told otherwise
+func (signer *ApiSigner) SetAlgorithm(algorithm string) error {
+	if err := checkAlgorithm(algorithm); err != nil {
+		return err
 	}
-	kMethod := hash([]byte(requestMethod), kSecret)
-	if signer.debug {
-		fmt.Printf("kMethod = %v\n", kMethod)
+	signer.algorithm = algorithm
+	return nil
+}
+
+// Algorithm returns the name of the signing algorithm in use
+func (signer *ApiSigner) Algorithm() string {
+	if signer.algorithm == "" {
+		return ALGORITHM_NAME
 	}
-	kQueryString := hash([]byte(queryString), kMethod)
+	return signer.algorithm
+}
+
+func (signer *ApiSigner) BuildAuthorizationHeaderValue(requestMethod, queryString string, header *http.Header, url string, body []byte) string {
+	authHeaderValue, trace := signer.BuildAuthorizationHeaderValueWithTrace(requestMethod, queryString, header, url, body)
 	if signer.debug {
-		fmt.Printf("kQueryString = %v\n", kQueryString)
-		fmt.Printf("body = %v\n", body)
-		fmt.Printf("bodyString = %s\n", string(body))
+		log.Info(trace.String())
 	}
-	kBody := hash(body, kQueryString)
-	if signer.debug {
-		fmt.Printf("kBody = %v\n", kBody)
-		fmt.Printf("requestHeader = %s\n", requestHeaders)
+	return authHeaderValue
+}
+
+// BuildAuthorizationHeaderValueWithTrace is like BuildAuthorizationHeaderValue
+// but also returns a SigningTrace explaining how the value was computed
+func (signer *ApiSigner) BuildAuthorizationHeaderValueWithTrace(requestMethod, queryString string, header *http.Header, url string, body []byte) (string, *SigningTrace) {
+	trace := &SigningTrace{
+		Algorithm:   signer.Algorithm(),
+		Credential:  signer.sharedKey,
+		Method:      requestMethod,
+		QueryString: queryString,
+		URL:         url,
 	}
-	hashed := hash([]byte(requestHeaders), kBody)
-	if signer.debug {
-		fmt.Printf("hashed = %v\n", hashed)
+	joinedHeaders := joinHeaders(header, signer.signedHeaders)
+	signatureKey := signer.hashRequest(requestMethod, queryString, body, joinedHeaders, trace)
+	signature := signString(signer.Algorithm(), signatureKey, url)
+	authHeaderValue := signer.buildAuthorizationHeaderValue(signedHeaderNames(header, signer.signedHeaders), signature)
+
+	trace.Signature = signature
+	trace.Authorization = authHeaderValue
+	return authHeaderValue, trace
+}
+
+func (signer *ApiSigner) buildAuthorizationHeaderValue(signedHeaders []string, signature string) string {
+	return AuthorizationHeaderValue(signer.Algorithm(), signer.sharedKey, signedHeaders, signature)
+}
+
+// hashRequest derives the signing key from the secret through the chain
+// method, query string, body and canonical headers. When trace is not nil
+// the inputs and fingerprints of the intermediate keys are recorded in it
+func (signer *ApiSigner) hashRequest(requestMethod, queryString string, body []byte, requestHeaders string, trace *SigningTrace) []byte {
+	algorithm := signer.Algorithm()
+	kSecret := []byte(SECRET_KEY_PREFIX + signer.secretKey)
+	kMethod := hmacSum(algorithm, []byte(requestMethod), kSecret)
+	kQueryString := hmacSum(algorithm, []byte(queryString), kMethod)
+	kBody := hmacSum(algorithm, body, kQueryString)
+	hashed := hmacSum(algorithm, []byte(requestHeaders), kBody)
+	if trace != nil {
+		trace.CanonicalHeaders = requestHeaders
+		trace.BodyLength = len(body)
+		trace.BodyDigest = digest(body)
+		trace.Keys = []TraceKey{
+			{Name: "kSecret", Fingerprint: redacted},
+			{Name: "kMethod", Fingerprint: fingerprint(kMethod)},
+			{Name: "kQueryString", Fingerprint: fingerprint(kQueryString)},
+			{Name: "kBody", Fingerprint: fingerprint(kBody)},
+			{Name: "kHeaders", Fingerprint: fingerprint(hashed)},
+		}
 	}
 	return hashed
 }
 
-func joinHeaders(header *http.Header) string {
+// joinHeaders builds the canonical header string: name:value pairs sorted by
+// name, each followed by a ;. Multiple values of a header are joined with a
+// comma in the order they were added. When signedHeaders is not empty only
+// those headers and SignedDate are included
+func joinHeaders(header *http.Header, signedHeaders []string) string {
 	var headers []string
+	for _, k := range signedHeaderKeys(header, signedHeaders) {
+		headers = append(headers, dhpHeaderName(k)+":"+strings.Join((*header)[k], ","))
+	}
+	joined := fmt.Sprintf("%s;", strings.Join(headers, ";"))
+	return joined
+}
+
+// signedHeaderNames returns the names of the headers joinHeaders includes,
+// in the same order. They are taken from the header itself as values may
+// contain a ; and so cannot be split off the canonical string
+func signedHeaderNames(header *http.Header, signedHeaders []string) []string {
+	keys := signedHeaderKeys(header, signedHeaders)
+	names := make([]string, len(keys))
+	for i, k := range keys {
+		names[i] = dhpHeaderName(k)
+	}
+	return names
+}
+
+// signedHeaderKeys returns the sorted keys of the headers to sign
+func signedHeaderKeys(header *http.Header, signedHeaders []string) []string {
 	var keys []string
 	for key := range *header {
-		keys = append(keys, key)
+		if len(signedHeaders) == 0 || isSignedHeader(key, signedHeaders) {
+			keys = append(keys, key)
+		}
 	}
 	sort.Strings(keys)
-	for _, k := range keys {
-		h := k
-		if h == "Signeddate" { // Ugly hack
-			h = "SignedDate"
-		}
-		headers = append(headers, h+":"+header.Get(h))
+	return keys
+}
+
+// dhpHeaderName returns the name of a header as DHP expects it in the string to sign
+func dhpHeaderName(key string) string {
+	if name, ok := dhpHeaderNames[key]; ok {
+		return name
 	}
-	joined := fmt.Sprintf("%s;", strings.Join(headers, ";"))
-	return joined
+	return key
 }
 
-func signString(signatureKey []byte, uriToBeSigned string) string {
-	signatureSlice := hash([]byte(uriToBeSigned), signatureKey)
-	return base64.StdEncoding.EncodeToString(signatureSlice)
+func isSignedHeader(key string, signedHeaders []string) bool {
+	if strings.EqualFold(key, "SignedDate") {
+		return true
+	}
+	for _, name := range signedHeaders {
+		if strings.EqualFold(key, name) {
+			return true
+		}
+	}
+	return false
 }
 
-func hash(data []byte, key []byte) []byte {
-	mac := hmac.New(sha256.New, key)
-	mac.Write(data)
-	return mac.Sum(nil)
+func signString(algorithm string, signatureKey []byte, uriToBeSigned string) string {
+	signatureSlice := hmacSum(algorithm, []byte(uriToBeSigned), signatureKey)
+	return base64.StdEncoding.EncodeToString(signatureSlice)
 }
@@ -0,0 +1,262 @@
+package client
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	RESPONSE_CODE_AUTHORIZATION_FORMAT  = 1260
+	RESPONSE_CODE_ALGORITHM_INVALID     = 1261
+	RESPONSE_CODE_SIGNATURE_MISSING     = 1262
+	RESPONSE_CODE_SHARED_KEY_MISSING    = 1263
+	RESPONSE_CODE_SHARED_KEY_INVALID    = 1264
+	RESPONSE_CODE_SIGNATURE_FAILED      = 1265
+	RESPONSE_CODE_UNAUTHORIZED_ACCESS   = 1266
+	RESPONSE_CODE_AUTHORIZATION_MISSING = 1267
+	RESPONSE_CODE_SIGNED_DATE_MISSING   = 1270
+	RESPONSE_CODE_SIGNED_DATE_FORMAT    = 3055
+)
+
+// DefaultSignatureValidity is how far the SignedDate of a request may be
+// off from the local clock, in either direction, before it is rejected
+var DefaultSignatureValidity = 15 * time.Minute
+
+// DefaultMaxBodySize is the largest request body, in bytes, Verify reads
+// to check the signature
+var DefaultMaxBodySize int64 = 10 << 20
+
+// KeyLookup returns the secret key belonging to a shared key.
+// It returns false when the shared key is not known
+type KeyLookup func(sharedKey string) (secretKey string, ok bool)
+
+// SecretsLookup returns all secrets currently accepted for a shared key.
+// It returns nothing when the shared key is not known
+type SecretsLookup func(sharedKey string) []string
+
+// AuthorizationHeader is the parsed form of a DHP Authorization header, e.g.
+// HmacSHA256;Credential:key;SignedHeaders:SignedDate;Signature:base64
+type AuthorizationHeader struct {
+	Algorithm     string
+	Credential    string
+	SignedHeaders []string
+	Signature     string
+}
+
+// VerificationError tells why a signed request was rejected.
+// DhpCode is the code DHP itself would answer with
+type VerificationError struct {
+	DhpCode int
+	Reason  string
+}
+
+func (e *VerificationError) Error() string {
+	if e.Reason == "" {
+		return fmt.Sprintf("%d: %s", e.DhpCode, StatusCodeToString(e.DhpCode))
+	}
+	return fmt.Sprintf("%d: %s: %s", e.DhpCode, StatusCodeToString(e.DhpCode), e.Reason)
+}
+
+func verificationError(dhpCode int, reason string) *VerificationError {
+	return &VerificationError{
+		DhpCode: dhpCode,
+		Reason:  reason,
+	}
+}
+
+// ParseAuthorizationHeader splits a DHP Authorization header value into its parts
+func ParseAuthorizationHeader(value string) (*AuthorizationHeader, error) {
+	if value == "" {
+		return nil, verificationError(RESPONSE_CODE_AUTHORIZATION_MISSING, "")
+	}
+	parts := strings.Split(value, ";")
+	if len(parts) != 4 {
+		return nil, verificationError(RESPONSE_CODE_AUTHORIZATION_FORMAT, "expected 4 parts separated by ;")
+	}
+	auth := &AuthorizationHeader{
+		Algorithm: parts[0],
+	}
+	for _, part := range parts[1:] {
+		kv := strings.SplitN(part, ":", 2)
+		if len(kv) != 2 {
+			return nil, verificationError(RESPONSE_CODE_AUTHORIZATION_FORMAT, "malformed part "+part)
+		}
+		switch kv[0] {
+		case "Credential":
+			auth.Credential = kv[1]
+		case "SignedHeaders":
+			if kv[1] != "" {
+				auth.SignedHeaders = strings.Split(kv[1], ",")
+			}
+		case "Signature":
+			auth.Signature = kv[1]
+		default:
+			return nil, verificationError(RESPONSE_CODE_AUTHORIZATION_FORMAT, "unknown part "+kv[0])
+		}
+	}
+	if checkAlgorithm(auth.Algorithm) != nil {
+		return nil, verificationError(RESPONSE_CODE_ALGORITHM_INVALID, auth.Algorithm)
+	}
+	if auth.Signature == "" {
+		return nil, verificationError(RESPONSE_CODE_SIGNATURE_MISSING, "")
+	}
+	if auth.Credential == "" {
+		return nil, verificationError(RESPONSE_CODE_SHARED_KEY_MISSING, "")
+	}
+	return auth, nil
+}
+
+// Verifier checks the signature of incoming requests signed the DHP way
+type Verifier struct {
+	KeyLookup     KeyLookup     // Resolves the Credential of a request to its secret
+	SecretsLookup SecretsLookup // Resolves the Credential to several secrets, takes precedence over KeyLookup
+	Validity      time.Duration // Allowed SignedDate offset, 0 means DefaultSignatureValidity
+	ReplayCache   ReplayCache   // Optional cache rejecting signatures which were seen before
+	Algorithms    []string      // Accepted signing algorithms, empty accepts all supported ones
+	MaxBodySize   int64         // Largest body read in bytes, 0 means DefaultMaxBodySize
+}
+
+// Verify checks the Authorization header of req using the default settings
+func Verify(req *http.Request, keyLookup KeyLookup) (*AuthorizationHeader, error) {
+	verifier := &Verifier{KeyLookup: keyLookup}
+	return verifier.Verify(req)
+}
+
+// Verify checks the Authorization header of req. It returns the parsed header
+// when the signature is valid and a *VerificationError otherwise. SignedDate
+// must be one of the signed headers, or it could be replaced after signing.
+// The request body is read and put back so it remains available to handlers
+func (verifier *Verifier) Verify(req *http.Request) (*AuthorizationHeader, error) {
+	auth, err := ParseAuthorizationHeader(req.Header.Get("Authorization"))
+	if err != nil {
+		return nil, err
+	}
+	if !verifier.accepts(auth.Algorithm) {
+		return auth, verificationError(RESPONSE_CODE_ALGORITHM_INVALID, auth.Algorithm+" not accepted")
+	}
+	signedDate := req.Header.Get("SignedDate")
+	if signedDate == "" {
+		return auth, verificationError(RESPONSE_CODE_SIGNED_DATE_MISSING, "")
+	}
+	if !signsDate(auth.SignedHeaders) {
+		return auth, verificationError(RESPONSE_CODE_UNAUTHORIZED_ACCESS, "SignedDate is not signed")
+	}
+	date, err := time.Parse(TIME_FORMAT, signedDate)
+	if err != nil {
+		return auth, verificationError(RESPONSE_CODE_SIGNED_DATE_FORMAT, signedDate)
+	}
+	validity := verifier.Validity
+	if validity <= 0 {
+		validity = DefaultSignatureValidity
+	}
+	if age := time.Since(date); age > validity || age < -validity {
+		return auth, verificationError(RESPONSE_CODE_SIGNATURE_EXPIRED, "signed at "+signedDate)
+	}
+	secretKeys := verifier.secrets(auth.Credential)
+	if len(secretKeys) == 0 {
+		return auth, verificationError(RESPONSE_CODE_SHARED_KEY_INVALID, auth.Credential)
+	}
+	maxBodySize := verifier.MaxBodySize
+	if maxBodySize <= 0 {
+		maxBodySize = DefaultMaxBodySize
+	}
+	body, err := readBody(req, maxBodySize)
+	if err != nil {
+		return auth, verificationError(RESPONSE_CODE_SIGNATURE_FAILED, err.Error())
+	}
+
+	joinedHeaders := joinSignedHeaders(req.Header, auth.SignedHeaders)
+	matched := false
+	for _, secretKey := range secretKeys {
+		signer := ApiSigner{}
+		signer.Init(auth.Credential, secretKey, false)
+		signer.SetAlgorithm(auth.Algorithm)
+		signatureKey := signer.hashRequest(req.Method, req.URL.RawQuery, body, joinedHeaders, nil)
+		signature := signString(auth.Algorithm, signatureKey, req.URL.Path)
+		if subtle.ConstantTimeCompare([]byte(signature), []byte(auth.Signature)) == 1 {
+			matched = true
+		}
+	}
+	if !matched {
+		return auth, verificationError(RESPONSE_CODE_UNAUTHORIZED_ACCESS, "signature mismatch")
+	}
+	if verifier.ReplayCache != nil && verifier.ReplayCache.Seen(auth.Credential+":"+auth.Signature, date.Add(validity)) {
+		return auth, verificationError(RESPONSE_CODE_SIGNATURE_EXPIRED, "signature already used")
+	}
+	return auth, nil
+}
+
+// secrets returns the secrets to try for a shared key
+func (verifier *Verifier) secrets(sharedKey string) []string {
+	if verifier.SecretsLookup != nil {
+		return verifier.SecretsLookup(sharedKey)
+	}
+	if verifier.KeyLookup != nil {
+		if secretKey, ok := verifier.KeyLookup(sharedKey); ok {
+			return []string{secretKey}
+		}
+	}
+	return nil
+}
+
+func (verifier *Verifier) accepts(algorithm string) bool {
+	if len(verifier.Algorithms) == 0 {
+		return true
+	}
+	for _, accepted := range verifier.Algorithms {
+		if accepted == algorithm {
+			return true
+		}
+	}
+	return false
+}
+
+// signsDate reports whether SignedDate is among the signed header names
+func signsDate(names []string) bool {
+	for _, name := range names {
+		if strings.EqualFold(name, "SignedDate") {
+			return true
+		}
+	}
+	return false
+}
+
+// joinSignedHeaders rebuilds the header string hashed by joinHeaders,
+// using the headers listed in the Authorization header in their order
+func joinSignedHeaders(header http.Header, names []string) string {
+	buffer := bytes.NewBufferString("")
+	for _, name := range names {
+		buffer.WriteString(name)
+		buffer.WriteString(":")
+		buffer.WriteString(strings.Join(header.Values(name), ","))
+		buffer.WriteString(";")
+	}
+	if len(names) == 0 {
+		buffer.WriteString(";")
+	}
+	return buffer.String()
+}
+
+// readBody returns the request body and restores it for later readers.
+// Bodies larger than maxSize are not read and fail
+func readBody(req *http.Request, maxSize int64) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return []byte{}, nil
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(req.Body, maxSize+1))
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > maxSize {
+		return nil, fmt.Errorf("body exceeds %d bytes", maxSize)
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
@@ -0,0 +1,145 @@
+package client
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testKeyLookup(sharedKey string) (string, bool) {
+	return "secret", sharedKey == "key"
+}
+
+// signedRequest returns a request signed by ApiClient.Sign
+func signedRequest(t *testing.T, method, path, query string, header http.Header, body []byte) *http.Request {
+	t.Helper()
+	client, err := NewClient(ApiClientConfig{ApiBaseUrl: "http://dhp", SigningKey: "key", SigningSecret: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.Sign(time.Now(), &header, path, query, method, body)
+	uri := "http://dhp" + path
+	if query != "" {
+		uri += "?" + query
+	}
+	req, err := http.NewRequest(method, uri, bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header = header
+	return req
+}
+
+func expectDhpCode(t *testing.T, err error, dhpCode int) {
+	t.Helper()
+	var verificationErr *VerificationError
+	if !errors.As(err, &verificationErr) {
+		t.Fatalf("expected a VerificationError with %d, got %v", dhpCode, err)
+	}
+	if verificationErr.DhpCode != dhpCode {
+		t.Fatalf("expected %d, got %v", dhpCode, err)
+	}
+}
+
+func TestVerifySignedRequest(t *testing.T) {
+	header := http.Header{}
+	header.Set("Api-Version", "2")
+	req := signedRequest(t, "POST", "/authentication/login", "applicationName=app", header, []byte(`{"loginId":"a"}`))
+	auth, err := Verify(req, testKeyLookup)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if auth.Credential != "key" {
+		t.Errorf("expected credential key, got %s", auth.Credential)
+	}
+	if body, _ := readBody(req, DefaultMaxBodySize); string(body) != `{"loginId":"a"}` {
+		t.Errorf("expected the body to be restored, got %s", body)
+	}
+}
+
+func TestVerifyRejectsAlteredHeader(t *testing.T) {
+	header := http.Header{}
+	header.Set("Api-Version", "2")
+	req := signedRequest(t, "GET", "/authentication/users/uuid", "", header, nil)
+	req.Header.Set("Api-Version", "3")
+	_, err := Verify(req, testKeyLookup)
+	expectDhpCode(t, err, RESPONSE_CODE_UNAUTHORIZED_ACCESS)
+}
+
+func TestVerifyRejectsUnknownKey(t *testing.T) {
+	req := signedRequest(t, "GET", "/x", "", http.Header{}, nil)
+	_, err := Verify(req, func(string) (string, bool) { return "", false })
+	expectDhpCode(t, err, RESPONSE_CODE_SHARED_KEY_INVALID)
+}
+
+func TestVerifyRejectsUnsignedSignedDate(t *testing.T) {
+	// Signed without SignedDate, which is added afterwards and so could be
+	// replaced at will to extend the life of a captured request
+	header := http.Header{}
+	header.Set("Api-Version", "2")
+	canonical := CanonicalHeaders(header)
+	signingKey := SigningKey(ALGORITHM_NAME, "secret", "GET", "", []byte{}, canonical)
+	signature := Signature(ALGORITHM_NAME, signingKey, "/x")
+	header.Set("Authorization", AuthorizationHeaderValue(ALGORITHM_NAME, "key", SignedHeaderNames(header), signature))
+	header.Set("SignedDate", time.Now().UTC().Format(TIME_FORMAT))
+	req, _ := http.NewRequest("GET", "http://dhp/x", nil)
+	req.Header = header
+
+	auth, err := Verify(req, testKeyLookup)
+	expectDhpCode(t, err, RESPONSE_CODE_UNAUTHORIZED_ACCESS)
+	if strings.Join(auth.SignedHeaders, ",") != "Api-Version" {
+		t.Errorf("expected only Api-Version to be signed, got %v", auth.SignedHeaders)
+	}
+}
+
+func TestVerifyRejectsExpiredSignature(t *testing.T) {
+	req := signedRequest(t, "GET", "/x", "", http.Header{}, nil)
+	verifier := &Verifier{KeyLookup: testKeyLookup, Validity: time.Minute}
+	date := time.Now().Add(-time.Hour).UTC().Format(TIME_FORMAT)
+	req.Header.Set("SignedDate", date)
+	_, err := verifier.Verify(req)
+	expectDhpCode(t, err, RESPONSE_CODE_SIGNATURE_EXPIRED)
+}
+
+func TestVerifyRejectsReplay(t *testing.T) {
+	verifier := &Verifier{KeyLookup: testKeyLookup, ReplayCache: NewMemoryReplayCache(10)}
+	req := signedRequest(t, "GET", "/x", "", http.Header{}, nil)
+	if _, err := verifier.Verify(req); err != nil {
+		t.Fatal(err)
+	}
+	_, err := verifier.Verify(req)
+	expectDhpCode(t, err, RESPONSE_CODE_SIGNATURE_EXPIRED)
+}
+
+func TestVerifyLimitsBodySize(t *testing.T) {
+	body := bytes.Repeat([]byte("a"), 64)
+	verifier := &Verifier{KeyLookup: testKeyLookup, MaxBodySize: 64}
+	if _, err := verifier.Verify(signedRequest(t, "POST", "/x", "", http.Header{}, body)); err != nil {
+		t.Fatal(err)
+	}
+	verifier.MaxBodySize = 63
+	_, err := verifier.Verify(signedRequest(t, "POST", "/x", "", http.Header{}, body))
+	expectDhpCode(t, err, RESPONSE_CODE_SIGNATURE_FAILED)
+}
+
+func TestVerifyAcceptedAlgorithms(t *testing.T) {
+	verifier := &Verifier{KeyLookup: testKeyLookup, Algorithms: []string{ALGORITHM_HMAC_SHA512}}
+	_, err := verifier.Verify(signedRequest(t, "GET", "/x", "", http.Header{}, nil))
+	expectDhpCode(t, err, RESPONSE_CODE_ALGORITHM_INVALID)
+}
+
+func TestParseAuthorizationHeader(t *testing.T) {
+	for value, dhpCode := range map[string]int{
+		"":                        RESPONSE_CODE_AUTHORIZATION_MISSING,
+		"HmacSHA256;Credential:k": RESPONSE_CODE_AUTHORIZATION_FORMAT,
+		"Md5;Credential:k;SignedHeaders:SignedDate;Signature:s":       RESPONSE_CODE_ALGORITHM_INVALID,
+		"HmacSHA256;Credential:k;SignedHeaders:SignedDate;Signature:": RESPONSE_CODE_SIGNATURE_MISSING,
+		"HmacSHA256;Credential:;SignedHeaders:SignedDate;Signature:s": RESPONSE_CODE_SHARED_KEY_MISSING,
+	} {
+		_, err := ParseAuthorizationHeader(value)
+		expectDhpCode(t, err, dhpCode)
+	}
+}
@@ -0,0 +1,181 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/Jeffail/gabs/v2"
+)
+
+const (
+	RESPONSE_CODE_USER_NOT_FOUND        = 1002
+	RESPONSE_CODE_INVALID_CREDENTIALS   = 1006
+	RESPONSE_CODE_USER_INACTIVE         = 1112
+	RESPONSE_CODE_INVALID_REFRESH_TOKEN = 1151
+	RESPONSE_CODE_ACCOUNT_LOCKED        = 1437
+
+	// AUTHENTICATION_API_VERSION is the Api-Version
+	// AuthenticationService uses unless told otherwise
+	AUTHENTICATION_API_VERSION = "2"
+)
+
+// AuthenticationService calls the authentication endpoints of the DHP user
+// management assembly. Failures are returned as *DHPError, which can be
+// matched against ErrInvalidCredentials, ErrAccessTokenExpired and friends
+type AuthenticationService struct {
+	client     *ApiClient
+	ApiVersion string // Sent as Api-Version header on Login, defaults to AUTHENTICATION_API_VERSION
+}
+
+// NewAuthenticationService returns a service using the given client,
+// which must be configured with the DHP_AUTH_URL and signing keys
+func NewAuthenticationService(client *ApiClient) *AuthenticationService {
+	return &AuthenticationService{
+		client:     client,
+		ApiVersion: AUTHENTICATION_API_VERSION,
+	}
+}
+
+// LoginRequest holds the credentials of a user
+type LoginRequest struct {
+	LoginId       string `json:"loginId"`
+	Password      string `json:"password"`
+	RefreshSecret string `json:"-"` // Optional, must be passed again when refreshing
+}
+
+// AccessCredential is the outcome of a login or token refresh
+type AccessCredential struct {
+	UserUUID     string    `json:"userUUID"`
+	AccessToken  string    `json:"accessToken"`
+	RefreshToken string    `json:"refreshToken,omitempty"`
+	ExpiresIn    int       `json:"expiresIn,omitempty"` // Lifetime of the access token in seconds, 0 when unknown
+	ExpiresAt    time.Time `json:"expiresAt,omitempty"` // Zero when unknown
+}
+
+type refreshTokenRequest struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+type recoverPasswordRequest struct {
+	LoginId string `json:"loginId"`
+}
+
+// Login authenticates a user
+func (s *AuthenticationService) Login(ctx context.Context, request LoginRequest) (*AccessCredential, error) {
+	header := s.header()
+	if s.ApiVersion != "" {
+		header.Set("Api-Version", s.ApiVersion)
+	}
+	if request.RefreshSecret != "" {
+		header.Set("refreshSecret", request.RefreshSecret)
+	}
+	body, _ := json.Marshal(&request)
+	response, err := s.client.Do(ctx, "POST", "/authentication/login", s.query(), header, body)
+	if err != nil {
+		return nil, err
+	}
+	return parseAccessCredential(response)
+}
+
+// Logout ends the session of a user
+func (s *AuthenticationService) Logout(ctx context.Context, userUUID, accessToken string) error {
+	header := s.header()
+	header.Set("AccessToken", accessToken)
+	_, err := s.client.Do(ctx, "POST", "/authentication/users/"+url.PathEscape(userUUID)+"/logout", s.query(), header, nil)
+	return err
+}
+
+// TokenStatus checks an access token. It returns nil when the token is
+// valid, otherwise an error matching ErrAccessTokenExpired or
+// ErrInvalidAccessToken
+func (s *AuthenticationService) TokenStatus(ctx context.Context, userUUID, accessToken string) error {
+	header := s.header()
+	header.Set("AccessToken", accessToken)
+	_, err := s.client.Do(ctx, "GET", "/authentication/users/"+url.PathEscape(userUUID)+"/tokenStatus", s.query(), header, nil)
+	return err
+}
+
+// RefreshToken trades a refresh token for a new access token. The refresh
+// secret is the one passed at login, if any
+func (s *AuthenticationService) RefreshToken(ctx context.Context, userUUID, refreshToken, refreshSecret string) (*AccessCredential, error) {
+	header := s.header()
+	if refreshSecret != "" {
+		header.Set("refreshSecret", refreshSecret)
+	}
+	body, _ := json.Marshal(&refreshTokenRequest{RefreshToken: refreshToken})
+	response, err := s.client.Do(ctx, "PUT", "/authentication/users/"+url.PathEscape(userUUID)+"/refreshToken", s.query(), header, body)
+	if err != nil {
+		return nil, err
+	}
+	credential, err := parseAccessCredential(response)
+	if err != nil {
+		return nil, err
+	}
+	if credential.UserUUID == "" {
+		credential.UserUUID = userUUID
+	}
+	if credential.RefreshToken == "" {
+		credential.RefreshToken = refreshToken
+	}
+	return credential, nil
+}
+
+// RecoverPassword starts the password recovery of a user
+func (s *AuthenticationService) RecoverPassword(ctx context.Context, loginId string) error {
+	body, _ := json.Marshal(&recoverPasswordRequest{LoginId: loginId})
+	_, err := s.client.Do(ctx, "POST", "/authentication/credential/recoverPassword", s.query(), s.header(), body)
+	return err
+}
+
+// header returns the headers of a request. Only Login is versioned,
+// the other endpoints are called without Api-Version
+func (s *AuthenticationService) header() *http.Header {
+	return &http.Header{}
+}
+
+func (s *AuthenticationService) query() string {
+	return "applicationName=" + url.QueryEscape(s.client.DHPApplicationName())
+}
+
+// parseAccessCredential reads the tokens from a login or refresh response.
+// Depending on the Api-Version DHP puts them under exchange.accessCredential,
+// exchange.user or directly under exchange
+func parseAccessCredential(response *Response) (*AccessCredential, error) {
+	jsonParsed, err := gabs.ParseJSON([]byte(response.Body))
+	if err != nil {
+		return nil, err
+	}
+	first := func(names ...string) string {
+		for _, name := range names {
+			for _, path := range []string{"exchange.accessCredential.", "exchange.user.", "exchange."} {
+				switch value := jsonParsed.Path(path + name).Data().(type) {
+				case string:
+					if value != "" {
+						return value
+					}
+				case float64:
+					return strconv.FormatFloat(value, 'f', -1, 64)
+				}
+			}
+		}
+		return ""
+	}
+	credential := &AccessCredential{
+		UserUUID:     first("userUUID"),
+		AccessToken:  first("accessToken"),
+		RefreshToken: first("refreshToken"),
+	}
+	if credential.AccessToken == "" {
+		return nil, errors.New("dhp: no access token in response")
+	}
+	if expiresIn, err := strconv.Atoi(first("expiresIn")); err == nil {
+		credential.ExpiresIn = expiresIn
+		credential.ExpiresAt = time.Now().Add(time.Duration(expiresIn) * time.Second).UTC()
+	}
+	return credential, nil
+}
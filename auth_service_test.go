@@ -0,0 +1,105 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// recordedRequest is what a service test server saw of a request
+type recordedRequest struct {
+	Method      string
+	Path        string // As decoded by the server
+	EscapedPath string // As sent on the wire
+	ApiVersion  string
+	VerifyErr   error
+}
+
+// newServiceServer answers every request with body and records it. The
+// client returned is signing with a key the server verifies
+func newServiceServer(t *testing.T, body string) (*ApiClient, func() []recordedRequest) {
+	var mu sync.Mutex
+	var requests []recordedRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := Verify(r, testKeyLookup)
+		mu.Lock()
+		requests = append(requests, recordedRequest{
+			Method:      r.Method,
+			Path:        r.URL.Path,
+			EscapedPath: r.URL.EscapedPath(),
+			ApiVersion:  r.Header.Get("Api-Version"),
+			VerifyErr:   err,
+		})
+		mu.Unlock()
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	client, err := NewClient(ApiClientConfig{ApiBaseUrl: srv.URL, DhpApplicationName: "my app", SigningKey: "key", SigningSecret: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client, func() []recordedRequest {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]recordedRequest(nil), requests...)
+	}
+}
+
+func TestAuthenticationServiceRequests(t *testing.T) {
+	client, requests := newServiceServer(t, `{"responseCode":"200","exchange":{"accessCredential":{"accessToken":"token","expiresIn":"3600"}}}`)
+	service := NewAuthenticationService(client)
+	ctx := context.Background()
+	if _, err := service.Login(ctx, LoginRequest{LoginId: "user", Password: "secret"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.Logout(ctx, "user uuid", "token"); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.TokenStatus(ctx, "user uuid", "token"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.RefreshToken(ctx, "user uuid", "refresh", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.RecoverPassword(ctx, "user"); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []recordedRequest{
+		{Method: "POST", Path: "/authentication/login", EscapedPath: "/authentication/login", ApiVersion: AUTHENTICATION_API_VERSION},
+		{Method: "POST", Path: "/authentication/users/user uuid/logout", EscapedPath: "/authentication/users/user%20uuid/logout"},
+		{Method: "GET", Path: "/authentication/users/user uuid/tokenStatus", EscapedPath: "/authentication/users/user%20uuid/tokenStatus"},
+		{Method: "PUT", Path: "/authentication/users/user uuid/refreshToken", EscapedPath: "/authentication/users/user%20uuid/refreshToken"},
+		{Method: "POST", Path: "/authentication/credential/recoverPassword", EscapedPath: "/authentication/credential/recoverPassword"},
+	}
+	got := requests()
+	if len(got) != len(expected) {
+		t.Fatalf("expected %d requests, got %d", len(expected), len(got))
+	}
+	for i, request := range got {
+		if request.VerifyErr != nil {
+			t.Errorf("%s %s: %v", request.Method, request.Path, request.VerifyErr)
+		}
+		request.VerifyErr = nil
+		if request != expected[i] {
+			t.Errorf("expected %+v, got %+v", expected[i], request)
+		}
+	}
+}
+
+func TestAuthenticationServiceEscapesUserUUID(t *testing.T) {
+	client, requests := newServiceServer(t, `{"responseCode":"200"}`)
+	service := NewAuthenticationService(client)
+	if err := service.TokenStatus(context.Background(), "a/b?c", "token"); err != nil {
+		t.Fatal(err)
+	}
+	request := requests()[0]
+	if request.VerifyErr != nil {
+		t.Errorf("%s %s: %v", request.Method, request.Path, request.VerifyErr)
+	}
+	if request.Path != "/authentication/users/a/b?c/tokenStatus" || request.EscapedPath != "/authentication/users/a%2Fb%3Fc/tokenStatus" {
+		t.Errorf("unexpected path %s (%s)", request.Path, request.EscapedPath)
+	}
+}
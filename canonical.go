@@ -0,0 +1,78 @@
+package client
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+)
+
+// The functions below expose every step of the DHP signing algorithm so
+// implementations in other languages can compare intermediate results.
+// Signing a request goes as follows:
+//
+//	canonicalHeaders := CanonicalHeaders(header)
+//	signingKey := SigningKey(algorithm, secretKey, method, queryString, body, canonicalHeaders)
+//	signature := Signature(algorithm, signingKey, path)
+//	value := AuthorizationHeaderValue(algorithm, sharedKey, SignedHeaderNames(header), signature)
+//
+// where algorithm is one of SigningAlgorithms(), normally ALGORITHM_NAME
+
+// CanonicalHeaders returns the header string hashed last in the signing chain:
+// name:value followed by a ; for every signed header, sorted by name. Names
+// are in MIME canonical form except for SignedDate, multiple values of a
+// header are joined with a comma. Even without headers the result is ";".
+// When signedHeaders are given only those headers and SignedDate are included
+func CanonicalHeaders(header http.Header, signedHeaders ...string) string {
+	canonical := canonicalHeader(header)
+	return joinHeaders(&canonical, signedHeaders)
+}
+
+// SignedHeaderNames returns the names of the headers CanonicalHeaders includes,
+// in the order they appear in the SignedHeaders part of the Authorization header
+func SignedHeaderNames(header http.Header, signedHeaders ...string) []string {
+	canonical := canonicalHeader(header)
+	return signedHeaderNames(&canonical, signedHeaders)
+}
+
+// canonicalHeader copies header with its names in MIME canonical form
+func canonicalHeader(header http.Header) http.Header {
+	canonical := http.Header{}
+	for name, values := range header {
+		for _, value := range values {
+			canonical.Add(name, value)
+		}
+	}
+	return canonical
+}
+
+// SigningKey derives the key used to sign the URL path. The secret, prefixed
+// with DHPWS, is the HMAC key for the method. Each result is then the key for
+// the next step: the query string (without ?), the body and finally the
+// canonical headers
+func SigningKey(algorithm, secretKey, method, queryString string, body []byte, canonicalHeaders string) []byte {
+	signer := ApiSigner{}
+	signer.Init("", secretKey, false)
+	signer.algorithm = algorithm
+	return signer.hashRequest(method, queryString, body, canonicalHeaders, nil)
+}
+
+// Signature signs the URL path, without host and query string,
+// and returns it base64 encoded
+func Signature(algorithm string, signingKey []byte, path string) string {
+	return signString(algorithm, signingKey, path)
+}
+
+// AuthorizationHeaderValue assembles the Authorization header value
+func AuthorizationHeaderValue(algorithm, sharedKey string, signedHeaders []string, signature string) string {
+	buffer := bytes.NewBufferString(algorithm)
+	buffer.WriteString(";")
+	buffer.WriteString("Credential:")
+	buffer.WriteString(sharedKey)
+	buffer.WriteString(";")
+	buffer.WriteString("SignedHeaders:")
+	buffer.WriteString(strings.Join(signedHeaders, ","))
+	buffer.WriteString(";")
+	buffer.WriteString("Signature:")
+	buffer.WriteString(signature)
+	return buffer.String()
+}
@@ -0,0 +1,85 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestCanonicalHeaders(t *testing.T) {
+	header := http.Header{
+		"SignedDate":  {"2019-02-01T12:00:00.000+0000"},
+		"x-tenant":    {"b", "a"},
+		"Api-Version": {"2"},
+	}
+	canonical := CanonicalHeaders(header)
+	if expected := "Api-Version:2;SignedDate:2019-02-01T12:00:00.000+0000;X-Tenant:b,a;"; canonical != expected {
+		t.Errorf("expected %q, got %q", expected, canonical)
+	}
+	if canonical := CanonicalHeaders(http.Header{}); canonical != ";" {
+		t.Errorf("expected ; without headers, got %q", canonical)
+	}
+	if canonical := CanonicalHeaders(header, "x-tenant"); canonical != "SignedDate:2019-02-01T12:00:00.000+0000;X-Tenant:b,a;" {
+		t.Errorf("expected only X-Tenant and SignedDate, got %q", canonical)
+	}
+}
+
+func TestSignedHeaderNames(t *testing.T) {
+	header := http.Header{
+		"SignedDate":   {"2019-02-01T12:00:00.000+0000"},
+		"Content-Type": {"application/json; charset=utf-8"},
+		"accesstoken":  {"token"},
+	}
+	expected := []string{"Accesstoken", "Content-Type", "SignedDate"}
+	if names := SignedHeaderNames(header); !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected %v, got %v", expected, names)
+	}
+	expected = []string{"Content-Type", "SignedDate"}
+	if names := SignedHeaderNames(header, "Content-Type"); !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected %v, got %v", expected, names)
+	}
+}
+
+func TestHeaderValueWithSemicolonVerifies(t *testing.T) {
+	var verifyErr error
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, verifyErr = Verify(r, testKeyLookup)
+		w.Write([]byte(`{"responseCode":"200"}`))
+	}))
+	defer srv.Close()
+	client, _ := NewClient(ApiClientConfig{ApiBaseUrl: srv.URL, SigningKey: "key", SigningSecret: "secret"})
+	header := &http.Header{}
+	header.Set("Content-Type", "application/json; charset=utf-8")
+	if _, err := client.Do(context.Background(), "POST", "/authentication/login", "applicationName=app", header, []byte(`{}`)); err != nil {
+		t.Fatal(err)
+	}
+	if verifyErr != nil {
+		t.Fatal(verifyErr)
+	}
+}
+
+func TestRepeatedHeaderVerifies(t *testing.T) {
+	var sent []string
+	var verifyErr error
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sent = r.Header.Values("X-Tenant")
+		_, verifyErr = Verify(r, testKeyLookup)
+		w.Write([]byte(`{"responseCode":"200"}`))
+	}))
+	defer srv.Close()
+	client, _ := NewClient(ApiClientConfig{ApiBaseUrl: srv.URL, SigningKey: "key", SigningSecret: "secret"})
+	header := &http.Header{}
+	header.Add("X-Tenant", "b")
+	header.Add("X-Tenant", "a")
+	if _, err := client.Do(context.Background(), "GET", "/x", "", header, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(sent, []string{"b", "a"}) {
+		t.Errorf("expected both values in order, got %v", sent)
+	}
+	if verifyErr != nil {
+		t.Fatal(verifyErr)
+	}
+}
@@ -0,0 +1,187 @@
+package client
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+// CredentialsProvider supplies the signing credentials of a client.
+// ApiClient asks for them on every request, so implementations which
+// read them from an external source should cache them
+type CredentialsProvider interface {
+	Credentials() (SigningCredential, error)
+}
+
+// Credentials implements CredentialsProvider so a SigningCredential
+// can be used as a static provider
+func (credential SigningCredential) Credentials() (SigningCredential, error) {
+	return credential, nil
+}
+
+// NewStaticCredentialsProvider returns a provider which always
+// supplies the given shared key and secret
+func NewStaticCredentialsProvider(sharedKey, secret string) CredentialsProvider {
+	return SigningCredential{SharedKey: sharedKey, Secret: secret}
+}
+
+// EnvCredentialsProvider reads the credentials from environment variables
+type EnvCredentialsProvider struct {
+	SharedKeyVar string
+	SecretVar    string
+}
+
+// NewEnvCredentialsProvider returns a provider reading the
+// shared key and secret from the named environment variables
+func NewEnvCredentialsProvider(sharedKeyVar, secretVar string) *EnvCredentialsProvider {
+	return &EnvCredentialsProvider{
+		SharedKeyVar: sharedKeyVar,
+		SecretVar:    secretVar,
+	}
+}
+
+// Credentials implements CredentialsProvider
+func (provider *EnvCredentialsProvider) Credentials() (SigningCredential, error) {
+	credential := SigningCredential{
+		SharedKey: os.Getenv(provider.SharedKeyVar),
+		Secret:    os.Getenv(provider.SecretVar),
+	}
+	if credential.SharedKey == "" || credential.Secret == "" {
+		return credential, fmt.Errorf("%s and %s must be set", provider.SharedKeyVar, provider.SecretVar)
+	}
+	return credential, nil
+}
+
+// DefaultReloadInterval is how often a FileCredentialsProvider
+// checks its files for changes when no interval is given
+var DefaultReloadInterval = 10 * time.Second
+
+// FileCredentialsProvider reads the credentials from mounted files, for
+// instance Kubernetes secrets, one file holding the shared key and one
+// holding the secret. Surrounding whitespace is ignored. The files are
+// checked for changes at most once per interval, so rotated secrets are
+// picked up without a restart
+type FileCredentialsProvider struct {
+	sharedKeyPath string
+	secretPath    string
+	interval      time.Duration
+
+	mu         sync.Mutex
+	checked    time.Time
+	modified   [2]time.Time
+	credential SigningCredential
+	err        error
+}
+
+// NewFileCredentialsProvider returns a provider reading the credentials from
+// the given files. An interval of 0 means DefaultReloadInterval
+func NewFileCredentialsProvider(sharedKeyPath, secretPath string, interval time.Duration) *FileCredentialsProvider {
+	if interval <= 0 {
+		interval = DefaultReloadInterval
+	}
+	return &FileCredentialsProvider{
+		sharedKeyPath: sharedKeyPath,
+		secretPath:    secretPath,
+		interval:      interval,
+	}
+}
+
+// Credentials implements CredentialsProvider
+func (provider *FileCredentialsProvider) Credentials() (SigningCredential, error) {
+	provider.mu.Lock()
+	defer provider.mu.Unlock()
+	now := time.Now()
+	if !provider.checked.IsZero() && now.Sub(provider.checked) < provider.interval {
+		return provider.credential, provider.err
+	}
+	provider.checked = now
+	var modified [2]time.Time
+	for i, path := range []string{provider.sharedKeyPath, provider.secretPath} {
+		info, err := os.Stat(path)
+		if err != nil {
+			provider.err = err
+			return provider.credential, err
+		}
+		modified[i] = info.ModTime()
+	}
+	if modified == provider.modified && provider.err == nil {
+		return provider.credential, nil
+	}
+	sharedKey, err := ioutil.ReadFile(provider.sharedKeyPath)
+	if err != nil {
+		provider.err = err
+		return provider.credential, err
+	}
+	secret, err := ioutil.ReadFile(provider.secretPath)
+	if err != nil {
+		provider.err = err
+		return provider.credential, err
+	}
+	provider.modified = modified
+	provider.credential = SigningCredential{
+		SharedKey: string(bytes.TrimSpace(sharedKey)),
+		Secret:    string(bytes.TrimSpace(secret)),
+	}
+	provider.err = nil
+	return provider.credential, nil
+}
+
+// VCAPCredentialsProvider reads the credentials from a service bound to a
+// Cloud Foundry app, as found in the VCAP_SERVICES environment variable.
+// VCAP_SERVICES is only parsed again when its value changes
+type VCAPCredentialsProvider struct {
+	Service      string // Name, label or tag of the bound service
+	SharedKeyKey string // Key of the shared key in the service credentials
+	SecretKey    string // Key of the secret in the service credentials
+
+	mu         sync.Mutex
+	loaded     bool
+	env        string
+	credential SigningCredential
+	err        error
+}
+
+// NewVCAPCredentialsProvider returns a provider reading the credentials
+// stored under sharedKeyKey and secretKey of the named service
+func NewVCAPCredentialsProvider(service, sharedKeyKey, secretKey string) *VCAPCredentialsProvider {
+	return &VCAPCredentialsProvider{
+		Service:      service,
+		SharedKeyKey: sharedKeyKey,
+		SecretKey:    secretKey,
+	}
+}
+
+// Credentials implements CredentialsProvider
+func (provider *VCAPCredentialsProvider) Credentials() (SigningCredential, error) {
+	env := os.Getenv("VCAP_SERVICES")
+	provider.mu.Lock()
+	defer provider.mu.Unlock()
+	if !provider.loaded || env != provider.env {
+		provider.credential, provider.err = provider.parse(env)
+		provider.env = env
+		provider.loaded = true
+	}
+	return provider.credential, provider.err
+}
+
+func (provider *VCAPCredentialsProvider) parse(env string) (SigningCredential, error) {
+	services, err := parseVCAPServices(env)
+	if err != nil {
+		return SigningCredential{}, err
+	}
+	service, ok := services.Lookup(provider.Service)
+	if !ok {
+		return SigningCredential{}, fmt.Errorf("service %s not bound", provider.Service)
+	}
+	credential := SigningCredential{
+		SharedKey: service.Credential(provider.SharedKeyKey),
+		Secret:    service.Credential(provider.SecretKey),
+	}
+	if credential.SharedKey == "" || credential.Secret == "" {
+		return credential, fmt.Errorf("service %s lacks %s or %s", provider.Service, provider.SharedKeyKey, provider.SecretKey)
+	}
+	return credential, nil
+}
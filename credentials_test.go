@@ -0,0 +1,130 @@
+package client
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func vcapServices(sharedKey string) string {
+	return `{"user-provided":[{"name":"dhp","label":"user-provided","tags":["dhp-config"],"credentials":{"signing_key":"` + sharedKey + `","signing_secret":"secret"}}]}`
+}
+
+func TestVCAPCredentialsProvider(t *testing.T) {
+	t.Setenv("VCAP_SERVICES", vcapServices("key"))
+	provider := NewVCAPCredentialsProvider("dhp-config", "signing_key", "signing_secret")
+	credential, err := provider.Credentials()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if credential != (SigningCredential{"key", "secret"}) {
+		t.Errorf("unexpected credential %v", credential)
+	}
+
+	t.Setenv("VCAP_SERVICES", vcapServices("rotated"))
+	if credential, _ := provider.Credentials(); credential.SharedKey != "rotated" {
+		t.Errorf("expected a changed VCAP_SERVICES to be parsed again, got %v", credential)
+	}
+
+	t.Setenv("VCAP_SERVICES", "{")
+	if _, err := provider.Credentials(); err == nil {
+		t.Error("expected malformed VCAP_SERVICES to fail")
+	}
+}
+
+func TestVCAPCredentialsProviderMissingService(t *testing.T) {
+	t.Setenv("VCAP_SERVICES", vcapServices("key"))
+	provider := NewVCAPCredentialsProvider("other", "signing_key", "signing_secret")
+	if _, err := provider.Credentials(); err == nil {
+		t.Error("expected an unbound service to fail")
+	}
+	provider = NewVCAPCredentialsProvider("dhp", "signing_key", "missing")
+	if _, err := provider.Credentials(); err == nil {
+		t.Error("expected missing credentials to fail")
+	}
+}
+
+func BenchmarkVCAPCredentialsProvider(b *testing.B) {
+	b.Setenv("VCAP_SERVICES", vcapServices("key"))
+	provider := NewVCAPCredentialsProvider("dhp-config", "signing_key", "signing_secret")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := provider.Credentials(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestEnvCredentialsProvider(t *testing.T) {
+	provider := NewEnvCredentialsProvider("TEST_DHP_SHARED_KEY", "TEST_DHP_SECRET")
+	t.Setenv("TEST_DHP_SHARED_KEY", "key")
+	t.Setenv("TEST_DHP_SECRET", "secret")
+	credential, err := provider.Credentials()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if credential != (SigningCredential{"key", "secret"}) {
+		t.Errorf("unexpected credential %v", credential)
+	}
+	t.Setenv("TEST_DHP_SECRET", "rotated")
+	if credential, _ := provider.Credentials(); credential.Secret != "rotated" {
+		t.Errorf("expected the changed secret, got %v", credential)
+	}
+	os.Unsetenv("TEST_DHP_SECRET")
+	if _, err := provider.Credentials(); err == nil {
+		t.Error("expected an unset secret to fail")
+	}
+}
+
+func writeCredentialFiles(t *testing.T, keyPath, secretPath, sharedKey, secret string, modified time.Time) {
+	t.Helper()
+	for path, value := range map[string]string{keyPath: sharedKey, secretPath: secret} {
+		if err := ioutil.WriteFile(path, []byte(value), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, modified, modified); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestFileCredentialsProviderReloads(t *testing.T) {
+	dir := t.TempDir()
+	keyPath := filepath.Join(dir, "key")
+	secretPath := filepath.Join(dir, "secret")
+	modified := time.Now().Add(-time.Hour)
+	writeCredentialFiles(t, keyPath, secretPath, "key\n", " secret\n", modified)
+	provider := NewFileCredentialsProvider(keyPath, secretPath, time.Minute)
+	credential, err := provider.Credentials()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if credential != (SigningCredential{"key", "secret"}) {
+		t.Errorf("expected the trimmed file contents, got %v", credential)
+	}
+
+	writeCredentialFiles(t, keyPath, secretPath, "rotated", "new secret", modified.Add(time.Minute))
+	if credential, _ := provider.Credentials(); credential.SharedKey != "key" {
+		t.Errorf("expected no reload within the interval, got %v", credential)
+	}
+	provider.checked = provider.checked.Add(-time.Minute)
+	credential, err = provider.Credentials()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if credential != (SigningCredential{"rotated", "new secret"}) {
+		t.Errorf("expected the rotated credentials, got %v", credential)
+	}
+
+	os.Remove(secretPath)
+	provider.checked = provider.checked.Add(-time.Minute)
+	credential, err = provider.Credentials()
+	if err == nil {
+		t.Error("expected a missing file to fail")
+	}
+	if credential.SharedKey != "rotated" {
+		t.Errorf("expected the last known credentials with the error, got %v", credential)
+	}
+}
@@ -0,0 +1,182 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Operations of CustomerCareService as recorded in AuditEvent.Action
+const (
+	CUSTOMERCARE_LOOKUP_PROFILE     = "lookupProfile"
+	CUSTOMERCARE_FORCE_LOGOUT       = "forceLogout"
+	CUSTOMERCARE_SEND_RECOVERY_CODE = "sendPasswordRecoveryCode"
+	CUSTOMERCARE_RESET_PASSWORD     = "changePasswordWithSMSCode"
+
+	// CUSTOMERCARE_API_VERSION is the Api-Version
+	// CustomerCareService uses unless told otherwise
+	CUSTOMERCARE_API_VERSION = "2"
+)
+
+// ErrOperatorRequired is returned by CustomerCareService when it audits
+// operations and the context does not name an operator
+var ErrOperatorRequired = errors.New("customer care operator required, see WithOperator")
+
+// AuditEvent describes an operation performed through CustomerCareService
+type AuditEvent struct {
+	Operator string    // Who performed the operation, see WithOperator
+	Action   string    // One of the CUSTOMERCARE_* operations
+	LoginId  string    // The user the operation was performed on, if known
+	Time     time.Time // When the operation was started
+	Err      error     // Why the operation failed, nil on success
+}
+
+// AuditFunc records customer care operations, e.g. in an audit log
+type AuditFunc func(ctx context.Context, event AuditEvent)
+
+type operatorContextKey struct{}
+
+// WithOperator returns a copy of ctx naming the operator on whose
+// behalf CustomerCareService operations are performed
+func WithOperator(ctx context.Context, operator string) context.Context {
+	return context.WithValue(ctx, operatorContextKey{}, operator)
+}
+
+// OperatorFromContext returns the operator set with WithOperator, or ""
+func OperatorFromContext(ctx context.Context) string {
+	operator, _ := ctx.Value(operatorContextKey{}).(string)
+	return operator
+}
+
+// CustomerCareService offers the operations support staff perform on user
+// accounts. Its client must be configured with the customer care signing
+// credentials rather than the application ones, see SERVICE_CUSTOMERCARE.
+// When Audit is set every operation is reported to it after completion and
+// operations fail with ErrOperatorRequired unless ctx names an operator
+type CustomerCareService struct {
+	client     *ApiClient
+	ApiVersion string    // Sent as Api-Version header, defaults to CUSTOMERCARE_API_VERSION
+	Audit      AuditFunc // Optional
+	SignedDate string    // Optional SignedDate to sign with instead of the current time, retries still use a fresh one
+}
+
+// NewCustomerCareService returns a service using the given client
+// and reporting to audit, which may be nil
+func NewCustomerCareService(client *ApiClient, audit AuditFunc) *CustomerCareService {
+	return &CustomerCareService{
+		client:     client,
+		ApiVersion: CUSTOMERCARE_API_VERSION,
+		Audit:      audit,
+	}
+}
+
+// UserAccount is a user as found by login id
+type UserAccount struct {
+	UserUUID string       `json:"userUUID"`
+	LoginId  string       `json:"loginId,omitempty"`
+	Profile  *UserProfile `json:"profile,omitempty"`
+}
+
+// ResetPasswordRequest changes a password using a code sent by SMS
+type ResetPasswordRequest struct {
+	Code        string
+	NewPassword string
+}
+
+type loginIdRequest struct {
+	LoginId string `json:"loginId"`
+}
+
+type resetPasswordWithCodeRequest struct {
+	Code            string `json:"code"`
+	NewPassword     string `json:"newPassword"`
+	ConfirmPassword string `json:"confirmPassword"`
+}
+
+// LookupProfile finds a user by login id
+func (s *CustomerCareService) LookupProfile(ctx context.Context, loginId string) (*UserAccount, error) {
+	var account *UserAccount
+	err := s.perform(ctx, CUSTOMERCARE_LOOKUP_PROFILE, loginId, func() error {
+		body, _ := json.Marshal(&loginIdRequest{LoginId: loginId})
+		response, err := s.do(ctx, "/usermanagement/users/profile", body)
+		if err != nil {
+			return err
+		}
+		account = &UserAccount{LoginId: loginId}
+		found, err := response.decodeExchange(account, "exchange.user", "exchange")
+		if err == nil && !found {
+			err = errors.New("dhp: no user in response")
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+// ForceLogout ends all sessions of a user
+func (s *CustomerCareService) ForceLogout(ctx context.Context, loginId string) error {
+	return s.perform(ctx, CUSTOMERCARE_FORCE_LOGOUT, loginId, func() error {
+		body, _ := json.Marshal(&loginIdRequest{LoginId: loginId})
+		_, err := s.do(ctx, "/authentication/users/logout", body)
+		return err
+	})
+}
+
+// SendRecoveryCode sends a password recovery code to a user
+func (s *CustomerCareService) SendRecoveryCode(ctx context.Context, loginId string) error {
+	return s.perform(ctx, CUSTOMERCARE_SEND_RECOVERY_CODE, loginId, func() error {
+		body, _ := json.Marshal(&loginIdRequest{LoginId: loginId})
+		_, err := s.do(ctx, "/authentication/credential/sendPasswordRecoveryCode", body)
+		return err
+	})
+}
+
+// ResetPassword sets a new password using the code the user received by SMS
+func (s *CustomerCareService) ResetPassword(ctx context.Context, request ResetPasswordRequest) error {
+	return s.perform(ctx, CUSTOMERCARE_RESET_PASSWORD, "", func() error {
+		body, _ := json.Marshal(&resetPasswordWithCodeRequest{
+			Code:            request.Code,
+			NewPassword:     request.NewPassword,
+			ConfirmPassword: request.NewPassword,
+		})
+		_, err := s.do(ctx, "/authentication/credential/changePasswordWithSMSCode", body)
+		return err
+	})
+}
+
+// perform runs an operation and reports it to the audit hook
+func (s *CustomerCareService) perform(ctx context.Context, action, loginId string, operation func() error) error {
+	if s.Audit == nil {
+		return operation()
+	}
+	operator := OperatorFromContext(ctx)
+	if operator == "" {
+		return ErrOperatorRequired
+	}
+	event := AuditEvent{
+		Operator: operator,
+		Action:   action,
+		LoginId:  loginId,
+		Time:     time.Now(),
+	}
+	event.Err = operation()
+	s.Audit(ctx, event)
+	return event.Err
+}
+
+func (s *CustomerCareService) do(ctx context.Context, apiEndpoint string, body []byte) (*Response, error) {
+	header := &http.Header{}
+	if s.ApiVersion != "" {
+		header.Set("Api-Version", s.ApiVersion)
+	}
+	if s.SignedDate != "" {
+		header.Set("SignedDate", s.SignedDate)
+	}
+	queryParams := "applicationName=" + url.QueryEscape(s.client.DHPApplicationName())
+	return s.client.Do(ctx, "POST", apiEndpoint, queryParams, header, body)
+}
@@ -0,0 +1,53 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCustomerCareServiceAudit(t *testing.T) {
+	client, _ := newServiceServer(t, `{"responseCode":"200","exchange":{"user":{"userUUID":"uuid","profile":{"givenName":"Jan"}}}}`)
+	var events []AuditEvent
+	service := NewCustomerCareService(client, func(ctx context.Context, event AuditEvent) {
+		events = append(events, event)
+	})
+	if _, err := service.LookupProfile(context.Background(), "jan@example.com"); err != ErrOperatorRequired {
+		t.Fatalf("expected ErrOperatorRequired, got %v", err)
+	}
+	account, err := service.LookupProfile(WithOperator(context.Background(), "operator"), "jan@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if account.UserUUID != "uuid" || account.LoginId != "jan@example.com" || account.Profile.GivenName != "Jan" {
+		t.Errorf("unexpected account %+v", account)
+	}
+	if len(events) != 1 || events[0].Operator != "operator" || events[0].Action != CUSTOMERCARE_LOOKUP_PROFILE {
+		t.Errorf("unexpected audit events %+v", events)
+	}
+}
+
+func TestCustomerCareServiceSignedDate(t *testing.T) {
+	var signedDate string
+	var verifyErr error
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		signedDate = r.Header.Get("SignedDate")
+		_, verifyErr = Verify(r, testKeyLookup)
+		w.Write([]byte(`{"responseCode":"200"}`))
+	}))
+	defer srv.Close()
+	client, _ := NewClient(ApiClientConfig{ApiBaseUrl: srv.URL, SigningKey: "key", SigningSecret: "secret"})
+	service := NewCustomerCareService(client, nil)
+	service.SignedDate = time.Now().Add(-time.Minute).UTC().Format(TIME_FORMAT)
+	if err := service.ForceLogout(context.Background(), "jan@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if signedDate != service.SignedDate {
+		t.Errorf("expected SignedDate %s, got %s", service.SignedDate, signedDate)
+	}
+	if verifyErr != nil {
+		t.Error(verifyErr)
+	}
+}
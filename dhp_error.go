@@ -0,0 +1,81 @@
+package client
+
+import (
+	"fmt"
+)
+
+// DHPError is returned by Do and DoRest when DHP answers with an error.
+// Use errors.Is to match it against the well-known errors below, or
+// errors.As to get at the codes and the full response
+type DHPError struct {
+	StatusCode int       // The HTTP status code
+	DhpCode    int       // The DHP response code, 0 when the body did not contain one
+	Message    string    // Human readable description of DhpCode
+	Response   *Response // The response which triggered the error
+}
+
+// Well-known DHP errors to use with errors.Is
+var (
+	ErrGatewayTimeout     = &DHPError{StatusCode: RESPONSE_CODE_GATEWAY_TIMEOUT}
+	ErrInvalidUserID      = &DHPError{DhpCode: RESPONSE_CODE_INVALID_USER_ID}
+	ErrAccessTokenExpired = &DHPError{DhpCode: RESPONSE_CODE_TOKEN_EXPIRED}
+	ErrInvalidAccessToken = &DHPError{DhpCode: RESPONSE_CODE_TOKEN_INVALID}
+	ErrValidationErrors   = &DHPError{DhpCode: RESPONSE_CODE_VALIDATION_ERRORS}
+	ErrSignatureExpired   = &DHPError{DhpCode: RESPONSE_CODE_SIGNATURE_EXPIRED}
+	ErrSharedKeyInvalid   = &DHPError{DhpCode: RESPONSE_CODE_SHARED_KEY_INVALID}
+	ErrUnauthorizedAccess = &DHPError{DhpCode: RESPONSE_CODE_UNAUTHORIZED_ACCESS}
+
+	ErrUserNotFound        = &DHPError{DhpCode: RESPONSE_CODE_USER_NOT_FOUND}
+	ErrInvalidCredentials  = &DHPError{DhpCode: RESPONSE_CODE_INVALID_CREDENTIALS}
+	ErrUserInactive        = &DHPError{DhpCode: RESPONSE_CODE_USER_INACTIVE}
+	ErrInvalidRefreshToken = &DHPError{DhpCode: RESPONSE_CODE_INVALID_REFRESH_TOKEN}
+	ErrAccountLocked       = &DHPError{DhpCode: RESPONSE_CODE_ACCOUNT_LOCKED}
+
+	ErrConsentRequired                   = &DHPError{DhpCode: RESPONSE_CODE_CONSENT_REQUIRED}
+	ErrDataCleanupInProgress             = &DHPError{DhpCode: RESPONSE_CODE_DATA_CLEANUP_IN_PROGRESS}
+	ErrCloseWithoutDataCleanNotSupported = &DHPError{DhpCode: RESPONSE_CODE_CLOSE_WITHOUT_DATA_CLEAN}
+)
+
+func newDHPError(response *Response) *DHPError {
+	message := StatusCodeToString(response.DhpCode)
+	if response.DhpCode == 0 {
+		message = fmt.Sprintf("HTTP %d", response.StatusCode)
+	}
+	return &DHPError{
+		StatusCode: response.StatusCode,
+		DhpCode:    response.DhpCode,
+		Message:    message,
+		Response:   response,
+	}
+}
+
+func (e *DHPError) Error() string {
+	if e.DhpCode == 0 {
+		return fmt.Sprintf("dhp: %s", e.Message)
+	}
+	return fmt.Sprintf("dhp: %d %s (HTTP %d)", e.DhpCode, e.Message, e.StatusCode)
+}
+
+// Is reports whether target is a *DHPError with the same DHP code. When target
+// carries no DHP code the HTTP status codes are compared instead
+func (e *DHPError) Is(target error) bool {
+	t, ok := target.(*DHPError)
+	if !ok {
+		return false
+	}
+	if t.DhpCode != 0 {
+		return t.DhpCode == e.DhpCode
+	}
+	return t.StatusCode != 0 && t.StatusCode == e.StatusCode
+}
+
+// isErrorResponse decides whether a response should be surfaced as a *DHPError
+func isErrorResponse(response *Response) bool {
+	if response.StatusCode >= 400 {
+		return true
+	}
+	if response.DhpCode == 0 || response.DhpCode == RESPONSE_CODE_VALID_TOKEN {
+		return false
+	}
+	return response.DhpCode < 200 || response.DhpCode > 299
+}
@@ -0,0 +1,127 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDHPErrorIs(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		err    *DHPError
+		target error
+		is     bool
+	}{
+		{"token expired", &DHPError{StatusCode: 401, DhpCode: 1008}, ErrAccessTokenExpired, true},
+		{"token invalid", &DHPError{StatusCode: 401, DhpCode: 1009}, ErrInvalidAccessToken, true},
+		{"other code", &DHPError{StatusCode: 401, DhpCode: 1009}, ErrAccessTokenExpired, false},
+		{"code wins over status", &DHPError{StatusCode: 504, DhpCode: 1008}, &DHPError{StatusCode: 504, DhpCode: 1009}, false},
+		{"status without code", &DHPError{StatusCode: 504}, ErrGatewayTimeout, true},
+		{"status of a coded error", &DHPError{StatusCode: 504, DhpCode: 1008}, ErrGatewayTimeout, true},
+		{"other status", &DHPError{StatusCode: 502}, ErrGatewayTimeout, false},
+		{"empty target", &DHPError{StatusCode: 502}, &DHPError{}, false},
+		{"other error type", &DHPError{StatusCode: 504}, errors.New("dhp"), false},
+	} {
+		if is := errors.Is(test.err, test.target); is != test.is {
+			t.Errorf("%s: expected %v, got %v", test.name, test.is, is)
+		}
+	}
+}
+
+func TestDHPErrorMessage(t *testing.T) {
+	if msg := (&DHPError{StatusCode: 401, DhpCode: 1008, Message: "Token expired"}).Error(); msg != "dhp: 1008 Token expired (HTTP 401)" {
+		t.Errorf("unexpected message %s", msg)
+	}
+	if msg := newDHPError(&Response{StatusCode: 502}).Error(); msg != "dhp: HTTP 502" {
+		t.Errorf("unexpected message %s", msg)
+	}
+}
+
+func TestDoErrors(t *testing.T) {
+	for _, test := range []struct {
+		name       string
+		status     int
+		body       string
+		dhpCode    int
+		target     error
+		successful bool
+	}{
+		{"success", 200, `{"responseCode":"200"}`, 200, nil, true},
+		{"valid token", 200, `{"responseCode":"1152"}`, 1152, nil, true},
+		{"token expired", 401, `{"responseCode":"1008"}`, 1008, ErrAccessTokenExpired, false},
+		{"error code with status 200", 200, `{"responseCode":"1009"}`, 1009, ErrInvalidAccessToken, false},
+		{"status without code", 504, `Gateway Timeout`, 0, ErrGatewayTimeout, false},
+		{"error field without code", 400, `{"error":"bad request"}`, 0, &DHPError{StatusCode: 400}, false},
+		{"error field with status 200", 200, `{"error":"ignored"}`, 0, nil, true},
+	} {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(test.status)
+			w.Write([]byte(test.body))
+		}))
+		client, _ := NewClient(ApiClientConfig{ApiBaseUrl: srv.URL, SigningKey: "key", SigningSecret: "secret"})
+		response, err := client.Do(context.Background(), "GET", "/x", "", &http.Header{}, nil)
+		srv.Close()
+		if response == nil {
+			t.Errorf("%s: expected a response, got %v", test.name, err)
+			continue
+		}
+		if response.DhpCode != test.dhpCode || response.StatusCode != test.status || response.Body != test.body {
+			t.Errorf("%s: unexpected response %+v", test.name, response)
+		}
+		if test.successful {
+			if err != nil {
+				t.Errorf("%s: expected no error, got %v", test.name, err)
+			}
+			continue
+		}
+		if !errors.Is(err, test.target) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.target, err)
+		}
+		var dhpErr *DHPError
+		if !errors.As(err, &dhpErr) || dhpErr.Response != response || dhpErr.DhpCode != test.dhpCode {
+			t.Errorf("%s: expected a *DHPError carrying the response, got %#v", test.name, err)
+		}
+	}
+}
+
+func TestDoTransportError(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+	client, _ := NewClient(ApiClientConfig{ApiBaseUrl: srv.URL, SigningKey: "key", SigningSecret: "secret"})
+	response, err := client.Do(context.Background(), "GET", "/x", "", &http.Header{}, nil)
+	if err == nil || response != nil {
+		t.Fatalf("expected only an error, got %v and %v", response, err)
+	}
+	var dhpErr *DHPError
+	if errors.As(err, &dhpErr) {
+		t.Errorf("expected a transport error, got %v", err)
+	}
+
+	legacy := client.SendSignedRequest("GET", "/x", "", &http.Header{}, nil)
+	if legacy.StatusCode != 500 || legacy.Body == "" {
+		t.Errorf("expected the legacy 500 response, got %+v", legacy)
+	}
+}
+
+func TestLegacyResponses(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"responseCode":"1008"}`))
+	}))
+	defer srv.Close()
+	client, _ := NewClient(ApiClientConfig{ApiBaseUrl: srv.URL, SigningKey: "key", SigningSecret: "secret"})
+	response := client.SendSignedRequest("GET", "/x", "", &http.Header{}, nil)
+	if response.StatusCode != http.StatusUnauthorized || response.DhpCode != RESPONSE_CODE_TOKEN_EXPIRED {
+		t.Errorf("expected the error response itself, got %+v", response)
+	}
+	response = client.SendRestRequest("GET", "/x", "", &http.Header{}, nil)
+	if response.StatusCode != http.StatusUnauthorized || response.DhpCode != RESPONSE_CODE_TOKEN_EXPIRED {
+		t.Errorf("expected the error response itself, got %+v", response)
+	}
+	if response := legacyResponse(nil, errors.New("building request")); response.Body != "error" {
+		t.Errorf("expected a Body of error, got %+v", response)
+	}
+}
@@ -1,28 +1,19 @@
 package command
 
 import (
+	"context"
 	"encoding/json"
 	_ "encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"git.aemian.com/dhp/client"
-	"github.com/Jeffail/gabs/v2"
-	"github.com/loafoe/cfutil"
 	"github.com/mitchellh/cli"
 	log "github.com/sirupsen/logrus"
-	"net/http"
 	"strings"
+	"time"
 )
 
-type AuthRequest struct {
-	LoginId  string `json:"loginId,omitempty"`
-	Password string `json:"password,omitempty"`
-}
-
-type RefreshRequest struct {
-	RefreshToken string `json:"refreshToken"`
-}
-
 type AuthCommand struct {
 	Revision          string
 	Version           string
@@ -42,6 +33,9 @@ Options:
   -secret=                    OPtional refresh secret
   -token=                     An access or refresh token
   -user=                      An UUID of the user"
+                              Without -user and -token the session stored by
+                              login is used, logout removes it
+  -timeout=30s                Deadline for the whole call
 	`
 	return strings.TrimSpace(helpText)
 }
@@ -51,24 +45,18 @@ func (au *AuthCommand) Synopsis() string {
 }
 
 func (au *AuthCommand) Run(args []string) int {
-	var apiEndpoint string
-	var queryParams string
-	var method string
-	var header *http.Header = &http.Header{}
-	var body []byte
-
 	// Setup configuration for the apiClient
-	config := client.ApiClientConfig{
-		ApiBaseUrl:         cfutil.Getenv("DHP_AUTH_URL"),
-		DhpApplicationName: cfutil.Getenv("DHP_APPLICATION_NAME"),
-		SigningKey:         cfutil.Getenv("DHP_SIGNING_KEY"),
-		SigningSecret:      cfutil.Getenv("DHP_SIGNING_SECRET"),
+	config, err := serviceConfig(client.SERVICE_AUTH)
+	if err != nil {
+		log.Error(err)
+		return 1
 	}
 	apiClient, err := client.NewClient(config)
 	if err != nil {
 		log.Error(err)
 		return 1
 	}
+	auth := client.NewAuthenticationService(apiClient)
 
 	// Setup and parse parameters
 	cmdFlags := flag.NewFlagSet("auth", flag.ContinueOnError)
@@ -79,85 +67,109 @@ func (au *AuthCommand) Run(args []string) int {
 	token := cmdFlags.String("token", "", "Access or Refresh token")
 	userId := cmdFlags.String("user", "", "The user UUID")
 	refreshSecret := cmdFlags.String("secret", "", "The refresh secret")
+	timeout := cmdFlags.Duration("timeout", 30*time.Second, "Deadline for the whole call")
 	if err := cmdFlags.Parse(args); err != nil {
 		log.Error(err)
 		return 1
 	}
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	// Without -user the session stored at login is used
+	var session *Session
+	if *userId == "" && *token == "" && *action != "login" && *action != "recover" {
+		if *action == "refresh" {
+			session, err = LoadSession()
+		} else {
+			session, err = currentSession(ctx)
+		}
+		if err != nil {
+			log.Error(err)
+			return 1
+		}
+		if session != nil {
+			*userId = session.UserUUID
+			*token = session.AccessToken
+			if *action == "refresh" {
+				*token = session.RefreshToken
+				if *refreshSecret == "" {
+					*refreshSecret = session.RefreshSecret
+				}
+			}
+		}
+	}
 
+	var credential *client.AccessCredential
 	switch *action {
 	case "login":
 		if *loginId == "" || *password == "" {
 			log.Error("username and password must be provided")
 			return 1
 		}
-		method = "POST"
-		apiEndpoint = "/authentication/login"
-		queryParams = "applicationName=" + config.DhpApplicationName
-		body, _ = json.Marshal(&AuthRequest{
-			LoginId:  *loginId,
-			Password: *password,
+		credential, err = auth.Login(ctx, client.LoginRequest{
+			LoginId:       *loginId,
+			Password:      *password,
+			RefreshSecret: *refreshSecret,
 		})
-		if *refreshSecret != "" {
-			header.Add("refreshSecret", *refreshSecret)
+		if err == nil {
+			session = &Session{RefreshSecret: *refreshSecret}
 		}
-		header.Add("Api-Version", "2")
-
 	case "logout":
 		if *userId == "" || *token == "" {
 			log.Error("user and token must be provided")
 			return 1
 		}
-		method = "POST"
-		apiEndpoint = "/authentication/users/" + *userId + "/logout"
-		queryParams = "applicationName=" + config.DhpApplicationName
-		header.Add("Authorization", "Bearer "+*token)
+		err = auth.Logout(ctx, *userId, *token)
+		var dhpErr *client.DHPError
+		if session != nil && (err == nil || errors.As(err, &dhpErr)) {
+			if err := ClearSession(); err != nil {
+				log.Error(err)
+			}
+		}
+		if err == nil {
+			au.Ui.Output("Logged out")
+		}
 	case "status":
 		if *userId == "" || *token == "" {
 			log.Error("user and token must be provided")
 			return 1
 		}
-		method = "GET"
-		apiEndpoint = "/authentication/users/" + *userId + "/tokenStatus"
-		queryParams = "applicationName=" + config.DhpApplicationName
-		header.Add("AccessToken", *token)
+		err = auth.TokenStatus(ctx, *userId, *token)
+		if err == nil {
+			au.Ui.Output("Access token is valid")
+		}
 	case "refresh":
 		if *userId == "" || *token == "" {
 			log.Error("user and token must be provided")
 			return 1
 		}
-		method = "PUT"
-		apiEndpoint = "/authentication/users/" + *userId + "/refreshToken"
-		queryParams = "applicationName=" + config.DhpApplicationName
-		body, _ = json.Marshal(&RefreshRequest{
-			RefreshToken: *token,
-		})
+		credential, err = auth.RefreshToken(ctx, *userId, *token, *refreshSecret)
 	case "recover":
 		if *loginId == "" {
 			log.Error("username must be provided")
 			return 1
 		}
-		method = "POST"
-		apiEndpoint = "/authentication/credential/recoverPassword"
-		queryParams = "applicationName=" + config.DhpApplicationName
-		body, _ = json.Marshal(&AuthRequest{
-			LoginId: *loginId,
-		})
-
+		err = auth.RecoverPassword(ctx, *loginId)
+		if err == nil {
+			au.Ui.Output("Password recovery started")
+		}
 	default:
 		return 1
 	}
-	response := apiClient.SendSignedRequest(method, apiEndpoint, queryParams, header, body)
-	if response.StatusCode < 200 {
-		log.Print(string(response.Body))
-		for _, e := range response.Errors {
-			log.Print(e)
-		}
-	}
-	jsonParsed, err := gabs.ParseJSON([]byte(response.Body))
 	if err != nil {
-		log.Error(err)
+		reportError(nil, err)
 		return 1
 	}
-	fmt.Println(jsonParsed.StringIndent("", "  "))
+	if credential == nil {
+		return 0
+	}
+	if session != nil {
+		session.update(credential)
+		if err := session.Save(); err != nil {
+			log.Error("Session not saved: ", err)
+		}
+	}
+	output, _ := json.MarshalIndent(credential, "", "  ")
+	fmt.Println(string(output))
 	return 0
 }
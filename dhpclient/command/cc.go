@@ -1,16 +1,16 @@
 package command
 
 import (
+	"context"
 	"encoding/json"
 	"flag"
 	"fmt"
 	"git.aemian.com/dhp/client"
-	"github.com/Jeffail/gabs/v2"
-	"github.com/loafoe/cfutil"
 	"github.com/mitchellh/cli"
 	log "github.com/sirupsen/logrus"
-	"net/http"
+	"os"
 	"strings"
+	"time"
 )
 
 type CustomerCare struct {
@@ -20,12 +20,6 @@ type CustomerCare struct {
 	Ui                cli.Ui
 }
 
-type ResetPasswordWithCodeRequest struct {
-	Code            string `json:"code"`
-	NewPassword     string `json:"newPassword"`
-	ConfirmPassword string `json:"confirmPassword"`
-}
-
 func (cc *CustomerCare) Help() string {
 	helpText := `
 Usage: dhpclient cc [options]
@@ -37,6 +31,9 @@ Options:
   -code=            SMS code (smsreset)
   -version=         API version to use
   -password=        New password (smsreset)
+  -date=            The SignedDate value to use instead of the current time
+  -operator=        Who performs the action, defaults to $USER
+  -timeout=30s      Deadline for the whole call
 	`
 	return strings.TrimSpace(helpText)
 }
@@ -46,70 +43,70 @@ func (cc *CustomerCare) Synopsis() string {
 }
 
 func (cc *CustomerCare) Run(args []string) int {
-	var apiEndpoint string
-	var queryParams string
-	var method string
-	var header *http.Header = &http.Header{}
-	var body []byte
-
-	config := client.ApiClientConfig{
-		ApiBaseUrl:         cfutil.Getenv("DHP_AUTH_URL"),
-		DhpApplicationName: cfutil.Getenv("DHP_APPLICATION_NAME"),
-		SigningKey:         cfutil.Getenv("DHP_CUSTOMERCARE_SIGNING_KEY"),
-		SigningSecret:      cfutil.Getenv("DHP_CUSTOMERCARE_SIGNING_SECRET"),
+	config, err := serviceConfig(client.SERVICE_CUSTOMERCARE)
+	if err != nil {
+		log.Error(err)
+		return 1
 	}
 	c, err := client.NewClient(config)
 	if err != nil {
 		log.Error(err)
 		return 1
 	}
+	customerCare := client.NewCustomerCareService(c, func(ctx context.Context, event client.AuditEvent) {
+		log.WithFields(log.Fields{
+			"operator": event.Operator,
+			"action":   event.Action,
+			"loginId":  event.LoginId,
+			"error":    event.Err,
+		}).Info("Customer care action")
+	})
 	cmdFlags := flag.NewFlagSet("cc", flag.ContinueOnError)
 	cmdFlags.Usage = func() { cc.Ui.Output(cc.Help()) }
 	action := cmdFlags.String("action", "profile", "Type of call. Defaults to profile")
 	username := cmdFlags.String("username", "", "The username (email) to lookup")
 	password := cmdFlags.String("password", "", "The password")
-	version := cmdFlags.String("version", "2", "The API version to use. Default is 2")
-	date := cmdFlags.String("date", "", "The SignedDate value to use")
+	version := cmdFlags.String("version", client.CUSTOMERCARE_API_VERSION, "The API version to use. Default is 2")
 	code := cmdFlags.String("code", "", "An SMS code")
+	date := cmdFlags.String("date", "", "The SignedDate value to use")
+	operator := cmdFlags.String("operator", os.Getenv("USER"), "Who performs the action")
+	timeout := cmdFlags.Duration("timeout", 30*time.Second, "Deadline for the whole call")
 	if err := cmdFlags.Parse(args); err != nil {
 		log.Error(err)
 		return 1
 	}
+	customerCare.ApiVersion = *version
+	customerCare.SignedDate = *date
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	ctx = client.WithOperator(ctx, *operator)
+
 	switch *action {
 	case "profile":
 		if *username == "" {
 			log.Error("username required")
 			return 1
 		}
-		method = "POST"
-		apiEndpoint = "/usermanagement/users/profile"
-		queryParams = "applicationName=" + config.DhpApplicationName
-		body, _ = json.Marshal(&AuthRequest{
-			LoginId: *username,
-		})
+		account, err := customerCare.LookupProfile(ctx, *username)
+		if err != nil {
+			reportError(nil, err)
+			return 1
+		}
+		output, _ := json.MarshalIndent(account, "", "  ")
+		fmt.Println(string(output))
+		return 0
 	case "logout":
 		if *username == "" {
 			log.Error("username required")
 			return 1
 		}
-		method = "POST"
-		apiEndpoint = "/authentication/users/logout"
-		queryParams = "applicationName=" + config.DhpApplicationName
-		body, _ = json.Marshal(&AuthRequest{
-			LoginId: *username,
-		})
+		err = customerCare.ForceLogout(ctx, *username)
 	case "recovery":
 		if *username == "" {
 			log.Error("username required")
 			return 1
 		}
-		method = "POST"
-		apiEndpoint = "/authentication/credential/sendPasswordRecoveryCode"
-		queryParams = "applicationName=" + config.DhpApplicationName
-		header.Set("Api-Version", "1")
-		body, _ = json.Marshal(&AuthRequest{
-			LoginId: *username,
-		})
+		err = customerCare.SendRecoveryCode(ctx, *username)
 	case "smsreset":
 		if *code == "" {
 			log.Error("SMS code required")
@@ -119,39 +116,18 @@ func (cc *CustomerCare) Run(args []string) int {
 			log.Error("New password required")
 			return 1
 		}
-		method = "POST"
-		apiEndpoint = "/authentication/credential/changePasswordWithSMSCode"
-		queryParams = "applicationName=" + config.DhpApplicationName
-		body, _ = json.Marshal(&ResetPasswordWithCodeRequest{
-			NewPassword:     *password,
-			ConfirmPassword: *password,
-			Code:            *code,
+		err = customerCare.ResetPassword(ctx, client.ResetPasswordRequest{
+			Code:        *code,
+			NewPassword: *password,
 		})
 	default:
-		log.Print("Unknown action: %s\n", *action)
+		log.Printf("Unknown action: %s\n", *action)
 		return 1
 	}
-	if *date != "" {
-		header.Set("SignedDate", *date)
-	}
-	if *version != "" {
-		header.Set("Api-Version", *version)
-	} else {
-		header.Set("Api-Version", "1")
-	}
-	response := c.SendSignedRequest(method, apiEndpoint, queryParams, header, body)
-	if response.StatusCode < 200 {
-		log.Print(string(response.Body))
-		for _, e := range response.Errors {
-			log.Print(e)
-		}
-	}
-	jsonParsed, err := gabs.ParseJSON([]byte(response.Body))
 	if err != nil {
-		log.Print(err)
+		reportError(nil, err)
 		return 1
 	}
-	fmt.Println(jsonParsed.StringIndent("", "  "))
-
+	cc.Ui.Output("Done")
 	return 0
 }
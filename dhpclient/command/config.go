@@ -0,0 +1,103 @@
+package command
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mitchellh/cli"
+	log "github.com/sirupsen/logrus"
+)
+
+type ConfigCommand struct {
+	Revision          string
+	Version           string
+	VersionPrerelease string
+	Ui                cli.Ui
+}
+
+func (cc *ConfigCommand) Help() string {
+	helpText := `
+Usage: dhpclient [-profile=name] config [options]
+  Manages the profiles in ~/.dhpclient/config. The profile is selected
+  with the global -profile flag, else DHP_PROFILE, else "default".
+  Settings are taken from command flags first, then from the DHP_*
+  environment variables and finally from the profile
+Options:
+  -action=show                Type of config action, defaults to show
+                              Available actions: list, show, set
+  -key=                       Setting to change, e.g. auth_url or signing_secret
+  -value=                     New value of the setting, empty removes it
+	`
+	return strings.TrimSpace(helpText)
+}
+
+func (cc *ConfigCommand) Synopsis() string {
+	return "Manages configuration profiles"
+}
+
+func (cc *ConfigCommand) Run(args []string) int {
+	cmdFlags := flag.NewFlagSet("config", flag.ContinueOnError)
+	cmdFlags.Usage = func() { cc.Ui.Output(cc.Help()) }
+	action := cmdFlags.String("action", "show", "Type of action. Defaults to show")
+	key := cmdFlags.String("key", "", "Setting to change")
+	value := cmdFlags.String("value", "", "New value of the setting")
+	if err := cmdFlags.Parse(args); err != nil {
+		log.Error(err)
+		return 1
+	}
+	profiles, err := LoadProfiles()
+	if err != nil {
+		log.Error(err)
+		return 1
+	}
+	selected, _ := SelectedProfile()
+
+	switch *action {
+	case "list":
+		for _, name := range profiles.Names() {
+			marker := " "
+			if name == selected {
+				marker = "*"
+			}
+			cc.Ui.Output(marker + " " + name)
+		}
+	case "show":
+		cc.Ui.Output("Profile: " + selected)
+		for _, k := range profileKeys {
+			variable := profileVariable(k)
+			value, source := profiles[selected][k], "profile"
+			if env, set := os.LookupEnv(variable); set && !fromProfile[variable] {
+				value, source = env, variable
+			}
+			if value == "" {
+				continue
+			}
+			cc.Ui.Output(fmt.Sprintf("  %-28s %s (%s)", k, maskValue(k, value), source))
+		}
+	case "set":
+		*key = strings.ToLower(*key)
+		if !isProfileKey(*key) {
+			log.Error("key must be one of ", strings.Join(profileKeys, ", "))
+			return 1
+		}
+		if profiles[selected] == nil {
+			profiles[selected] = map[string]string{}
+		}
+		if *value == "" {
+			delete(profiles[selected], *key)
+		} else {
+			profiles[selected][*key] = *value
+		}
+		if err := profiles.Save(); err != nil {
+			log.Error(err)
+			return 1
+		}
+		cc.Ui.Output(fmt.Sprintf("%s: %s = %s", selected, *key, maskValue(*key, *value)))
+	default:
+		cc.Ui.Output(cc.Help())
+		return 1
+	}
+	return 0
+}
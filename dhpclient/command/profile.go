@@ -0,0 +1,188 @@
+package command
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	// PROFILE_ENV names the environment variable selecting the profile
+	PROFILE_ENV = "DHP_PROFILE"
+	// DEFAULT_PROFILE is used when no profile is selected
+	DEFAULT_PROFILE = "default"
+)
+
+// profileKeys are the settings a profile can hold. Each one stands for the
+// environment variable DHP_ followed by the key in upper case, e.g.
+// auth_url for DHP_AUTH_URL
+var profileKeys = []string{
+	"auth_url",
+	"subscription_service_url",
+	"application_name",
+	"proposition_name",
+	"signing_key",
+	"signing_secret",
+	"subscription_signing_key",
+	"subscription_signing_secret",
+	"customercare_signing_key",
+	"customercare_signing_secret",
+	"observation_service_url",
+	"observation_signing_key",
+	"observation_signing_secret",
+	"service_binding",
+}
+
+// fromProfile records the variables set by ApplyProfile
+var fromProfile = map[string]bool{}
+
+// Profiles are the named sets of settings in the dhpclient config file
+type Profiles map[string]map[string]string
+
+// ConfigPath returns the location of the dhpclient config file
+func ConfigPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".dhpclient", "config"), nil
+}
+
+// LoadProfiles reads the config file. A missing file holds no profiles.
+// The file consists of [profile] sections with key = value lines, lines
+// starting with # are comments
+func LoadProfiles() (Profiles, error) {
+	profiles := Profiles{}
+	path, err := ConfigPath()
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return profiles, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var section map[string]string
+	scanner := bufio.NewScanner(file)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+			continue
+		case strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]"):
+			name := strings.TrimSpace(line[1 : len(line)-1])
+			if profiles[name] == nil {
+				profiles[name] = map[string]string{}
+			}
+			section = profiles[name]
+		default:
+			kv := strings.SplitN(line, "=", 2)
+			if len(kv) != 2 || section == nil {
+				return nil, fmt.Errorf("%s:%d: expected key = value inside a [profile]", path, n)
+			}
+			section[strings.ToLower(strings.TrimSpace(kv[0]))] = strings.TrimSpace(kv[1])
+		}
+	}
+	return profiles, scanner.Err()
+}
+
+// Save writes the profiles to the config file, readable by the owner only
+func (profiles Profiles) Save() error {
+	path, err := ConfigPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	var b strings.Builder
+	for _, name := range profiles.Names() {
+		fmt.Fprintf(&b, "[%s]\n", name)
+		profile := profiles[name]
+		keys := make([]string, 0, len(profile))
+		for key := range profile {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			fmt.Fprintf(&b, "%s = %s\n", key, profile[key])
+		}
+		b.WriteString("\n")
+	}
+	return ioutil.WriteFile(path, []byte(b.String()), 0600)
+}
+
+// Names returns the profile names in alphabetical order
+func (profiles Profiles) Names() []string {
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SelectedProfile returns the name of the profile in use
+// and whether it was asked for explicitly
+func SelectedProfile() (string, bool) {
+	if name := os.Getenv(PROFILE_ENV); name != "" {
+		return name, true
+	}
+	return DEFAULT_PROFILE, false
+}
+
+// ApplyProfile exports the settings of the selected profile as DHP_*
+// environment variables, leaving variables which are already set alone.
+// This gives command line flags precedence over the environment and
+// the environment precedence over the config file
+func ApplyProfile() error {
+	name, explicit := SelectedProfile()
+	profiles, err := LoadProfiles()
+	if err != nil {
+		return err
+	}
+	profile, ok := profiles[name]
+	if !ok {
+		if explicit {
+			return fmt.Errorf("profile %s not found in config file", name)
+		}
+		return nil
+	}
+	for key, value := range profile {
+		variable := profileVariable(key)
+		if _, set := os.LookupEnv(variable); !set {
+			os.Setenv(variable, value)
+			fromProfile[variable] = true
+		}
+	}
+	return nil
+}
+
+func profileVariable(key string) string {
+	return "DHP_" + strings.ToUpper(key)
+}
+
+func isProfileKey(key string) bool {
+	for _, k := range profileKeys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// maskValue hides secrets when showing settings
+func maskValue(key, value string) string {
+	if value == "" || !strings.Contains(key, "secret") {
+		return value
+	}
+	return "********"
+}
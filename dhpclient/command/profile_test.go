@@ -0,0 +1,54 @@
+package command
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	client "git.aemian.com/dhp/client"
+)
+
+func TestApplyProfileObservationKeys(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv(PROFILE_ENV, "obs")
+	variables := []string{"DHP_OBSERVATION_SERVICE_URL", "DHP_OBSERVATION_SIGNING_KEY", "DHP_OBSERVATION_SIGNING_SECRET", "VCAP_SERVICES"}
+	for _, variable := range variables {
+		t.Setenv(variable, "")
+		os.Unsetenv(variable)
+	}
+	defer func() {
+		for _, variable := range variables {
+			delete(fromProfile, variable)
+		}
+	}()
+
+	config := `[obs]
+observation_service_url = https://observation
+observation_signing_key = key
+observation_signing_secret = secret
+`
+	if err := os.MkdirAll(filepath.Join(home, ".dhpclient"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(home, ".dhpclient", "config"), []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ApplyProfile(); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"observation_service_url", "observation_signing_key", "observation_signing_secret"} {
+		if !isProfileKey(key) {
+			t.Errorf("expected %s to be a profile key", key)
+		}
+	}
+	serviceConfig, err := client.LoadServiceConfig(client.SERVICE_OBSERVATION, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if serviceConfig.ApiBaseUrl != "https://observation" || serviceConfig.SigningKey != "key" || serviceConfig.SigningSecret != "secret" {
+		t.Errorf("unexpected observation config %+v", serviceConfig)
+	}
+}
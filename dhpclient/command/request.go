@@ -1,15 +1,16 @@
 package command
 
 import (
+	"context"
 	_ "encoding/json"
 	"flag"
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	"git.aemian.com/dhp/client"
 	"github.com/Jeffail/gabs/v2"
-	"github.com/loafoe/cfutil"
 	"github.com/mitchellh/cli"
 	log "github.com/sirupsen/logrus"
 )
@@ -32,6 +33,7 @@ Options:
   -version=                           Optional set Api-Version header
   -endpoint=/some/path                The endpoint of the request
   -headers="..."                      Headers to add. Separate with ;
+  -timeout=30s                        Deadline for the whole call
 	`
 	return strings.TrimSpace(helpText)
 }
@@ -49,25 +51,21 @@ func (uc *RequestCommand) Run(args []string) int {
 	version := cmdFlags.String("version", "", "The API version to use")
 	body := cmdFlags.String("body", "", "Optional JSON body of request")
 	headers := cmdFlags.String("headers", "", "Headers to add to request. Separate with ;")
-	service := cmdFlags.String("service", "subscription", "IAM or IDM request")
+	serviceName := cmdFlags.String("service", "subscription", "IAM or IDM request")
+	timeout := cmdFlags.Duration("timeout", 30*time.Second, "Deadline for the whole call")
 	if err := cmdFlags.Parse(args); err != nil {
 		return 1
 	}
-	baseURL := cfutil.Getenv("DHP_SUBSCRIPTION_SERVICE_URL")
-	signingKey := cfutil.Getenv("DHP_SUBSCRIPTION_SIGNING_KEY")
-	signingSecret := cfutil.Getenv("DHP_SUBSCRIPTION_SIGNING_SECRET")
-
-	if *service == "authorize" {
-		baseURL = cfutil.Getenv("DHP_AUTH_URL")
-		signingKey = cfutil.Getenv("DHP_CUSTOMERCARE_SIGNING_KEY")
-		signingSecret = cfutil.Getenv("DHP_CUSTOMERCARE_SIGNING_SECRET")
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	service := client.SERVICE_SUBSCRIPTION
+	if *serviceName == "authorize" {
+		service = client.SERVICE_CUSTOMERCARE
 	}
-
-	config := client.ApiClientConfig{
-		ApiBaseUrl:         baseURL,
-		DhpApplicationName: cfutil.Getenv("DHP_APPLICATION_NAME"),
-		SigningKey:         signingKey,
-		SigningSecret:      signingSecret,
+	config, err := serviceConfig(service)
+	if err != nil {
+		log.Error(err)
+		return 1
 	}
 	c, err := client.NewClient(config)
 	if err != nil {
@@ -96,11 +94,10 @@ func (uc *RequestCommand) Run(args []string) int {
 	if *version != "" {
 		header.Set("Api-Version", *version)
 	}
-	response := c.SendSignedRequest(*method, apiEndpoint, queryParams, header, []byte(*body))
-	if response.StatusCode < 200 {
-		log.Print(string(response.Body))
-		for _, e := range response.Errors {
-			log.Print(e)
+	response, err := c.Do(ctx, *method, apiEndpoint, queryParams, header, []byte(*body))
+	if err != nil {
+		if reportError(response, err) {
+			log.Print(string(response.Body))
 		}
 		return 1
 	}
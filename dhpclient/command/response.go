@@ -0,0 +1,27 @@
+package command
+
+import (
+	"errors"
+
+	"git.aemian.com/dhp/client"
+	log "github.com/sirupsen/logrus"
+)
+
+// reportError logs the outcome of a failed DHP call. It returns false
+// when there is no response worth printing, e.g. on network failures.
+// Without a response the one carried by the error is used
+func reportError(response *client.Response, err error) bool {
+	var dhpErr *client.DHPError
+	if !errors.As(err, &dhpErr) {
+		log.Error(err)
+		return false
+	}
+	log.Error(dhpErr)
+	if response == nil {
+		response = dhpErr.Response
+	}
+	for _, e := range response.Errors {
+		log.Print(e)
+	}
+	return true
+}
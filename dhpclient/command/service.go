@@ -0,0 +1,13 @@
+package command
+
+import (
+	"git.aemian.com/dhp/client"
+	"github.com/loafoe/cfutil"
+)
+
+// serviceConfig loads the configuration of a DHP service from the
+// VCAP_SERVICES binding named by DHP_SERVICE_BINDING, falling back
+// to the DHP_* environment variables
+func serviceConfig(service string) (client.ApiClientConfig, error) {
+	return client.LoadServiceConfig(service, cfutil.Getenv(client.SERVICE_BINDING_ENV))
+}
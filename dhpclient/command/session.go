@@ -0,0 +1,158 @@
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"git.aemian.com/dhp/client"
+	log "github.com/sirupsen/logrus"
+)
+
+// SessionRefreshMargin is how long before expiry the access token of
+// a session is refreshed when a command picks it up
+var SessionRefreshMargin = 2 * time.Minute
+
+// Session is the login state of a profile, kept between CLI calls
+type Session struct {
+	UserUUID      string    `json:"userUUID"`
+	AccessToken   string    `json:"accessToken"`
+	RefreshToken  string    `json:"refreshToken,omitempty"`
+	RefreshSecret string    `json:"refreshSecret,omitempty"` // Passed at login, needed to refresh
+	ExpiresAt     time.Time `json:"expiresAt,omitempty"`     // Zero when DHP did not say
+}
+
+// sessionPath returns the session file of the selected profile
+func sessionPath() (string, error) {
+	path, err := ConfigPath()
+	if err != nil {
+		return "", err
+	}
+	profile, _ := SelectedProfile()
+	return filepath.Join(filepath.Dir(path), "sessions", profile+".json"), nil
+}
+
+// LoadSession reads the session of the selected profile, nil when there is none
+func LoadSession() (*Session, error) {
+	path, err := sessionPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	session := &Session{}
+	if err := json.Unmarshal(data, session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// Save stores the session, readable by the owner only
+func (session *Session) Save() error {
+	path, err := sessionPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(session, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0600)
+}
+
+// ClearSession removes the session of the selected profile
+func ClearSession() error {
+	path, err := sessionPath()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// expiring reports whether the access token is about to expire
+func (session *Session) expiring() bool {
+	return !session.ExpiresAt.IsZero() && time.Until(session.ExpiresAt) < SessionRefreshMargin
+}
+
+// update takes the tokens from a login or refresh
+func (session *Session) update(credential *client.AccessCredential) {
+	if credential.UserUUID != "" {
+		session.UserUUID = credential.UserUUID
+	}
+	session.AccessToken = credential.AccessToken
+	if credential.RefreshToken != "" {
+		session.RefreshToken = credential.RefreshToken
+	}
+	session.ExpiresAt = credential.ExpiresAt
+}
+
+// refresh gets a new access token through the /refreshToken endpoint
+// and stores the session
+func (session *Session) refresh(ctx context.Context) error {
+	if session.RefreshToken == "" {
+		return errors.New("session has no refresh token, login again")
+	}
+	config, err := serviceConfig(client.SERVICE_AUTH)
+	if err != nil {
+		return err
+	}
+	apiClient, err := client.NewClient(config)
+	if err != nil {
+		return err
+	}
+	auth := client.NewAuthenticationService(apiClient)
+	credential, err := auth.RefreshToken(ctx, session.UserUUID, session.RefreshToken, session.RefreshSecret)
+	if err != nil {
+		return err
+	}
+	session.update(credential)
+	return session.Save()
+}
+
+// currentSession returns the session of the selected profile,
+// refreshed when its access token is about to expire
+func currentSession(ctx context.Context) (*Session, error) {
+	session, err := LoadSession()
+	if err != nil || session == nil {
+		return nil, err
+	}
+	if session.expiring() {
+		log.Info("Access token about to expire, refreshing")
+		if err := session.refresh(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return session, nil
+}
+
+// sessionDefaults fills in the user and access token from the session of
+// the selected profile when no user was given on the command line
+func sessionDefaults(ctx context.Context, userId, token *string) error {
+	if *userId != "" {
+		return nil
+	}
+	session, err := currentSession(ctx)
+	if err != nil || session == nil {
+		return err
+	}
+	*userId = session.UserUUID
+	if *token == "" {
+		*token = session.AccessToken
+	}
+	return nil
+}
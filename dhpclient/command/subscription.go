@@ -1,16 +1,16 @@
 package command
 
 import (
-	_ "encoding/json"
+	"context"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"git.aemian.com/dhp/client"
-	"github.com/Jeffail/gabs/v2"
-	"github.com/loafoe/cfutil"
 	"github.com/mitchellh/cli"
 	log "github.com/sirupsen/logrus"
-	"net/http"
 	"strings"
+	"time"
 )
 
 type SubscriptionCommand struct {
@@ -26,9 +26,16 @@ Usage: dhpclient subscription [options]
   Performs calls to the DHP subscription assembly
 Options:
   -action=tc        Type of call, defaults to Terms & Conditions (tc)
-                    Available action: [tc, close]
-  -consent=         The consent code. Default=2		    
+                    Available action: [tc, accept, list, close]
+  -consent=         The consent code. Default=2
+  -doc=             The document id to accept
+  -version=         The document version to accept
+  -country=         The country code of the accepted document
+  -keepdata         Close without deleting the data of the user
   -user=  			The user UUID to use in actions
+  -token=           A user access token
+                    Without -user the session stored by auth login is used
+  -timeout=30s      Deadline for the whole call
 	`
 	return strings.TrimSpace(helpText)
 }
@@ -38,23 +45,17 @@ func (sc *SubscriptionCommand) Synopsis() string {
 }
 
 func (sc *SubscriptionCommand) Run(args []string) int {
-	var apiEndpoint string
-	var queryParams string
-	var method string
-	var header *http.Header = &http.Header{}
-
-	config := client.ApiClientConfig{
-		ApiBaseUrl:         cfutil.Getenv("DHP_SUBSCRIPTION_SERVICE_URL"),
-		DhpApplicationName: cfutil.Getenv("DHP_APPLICATION_NAME"),
-		SigningKey:         cfutil.Getenv("DHP_SUBSCRIPTION_SIGNING_KEY"),
-		SigningSecret:      cfutil.Getenv("DHP_SUBSCRIPTION_SIGNING_SECRET"),
-		PropositionName:    cfutil.Getenv("DHP_PROPOSITION_NAME"),
+	config, err := serviceConfig(client.SERVICE_SUBSCRIPTION)
+	if err != nil {
+		log.Error(err)
+		return 1
 	}
 	c, err := client.NewClient(config)
 	if err != nil {
 		log.Error(err)
 		return 1
 	}
+	subscriptions := client.NewSubscriptionService(c)
 
 	cmdFlags := flag.NewFlagSet("subscription", flag.ContinueOnError)
 	cmdFlags.Usage = func() { sc.Ui.Output(sc.Help()) }
@@ -62,52 +63,70 @@ func (sc *SubscriptionCommand) Run(args []string) int {
 	userId := cmdFlags.String("user", "", "The user id to use in the call")
 	consent := cmdFlags.String("consent", "2", "The consent code")
 	token := cmdFlags.String("token", "", "A user access token")
+	documentId := cmdFlags.String("doc", "", "The document id to accept")
+	documentVersion := cmdFlags.String("version", "", "The document version to accept")
+	country := cmdFlags.String("country", "", "The country code of the document")
+	keepData := cmdFlags.Bool("keepdata", false, "Close without deleting data")
+	timeout := cmdFlags.Duration("timeout", 30*time.Second, "Deadline for the whole call")
 	if err := cmdFlags.Parse(args); err != nil {
 		log.Error(err)
 		return 1
 	}
-	body := []byte{}
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	if err := sessionDefaults(ctx, userId, token); err != nil {
+		log.Error(err)
+		return 1
+	}
+	if *userId == "" {
+		log.Error("user-id required for ", *action)
+		return 1
+	}
+
+	var result interface{}
 	switch *action {
 	case "close":
-		if *userId == "" {
-			log.Error("user-id required to get Terms & Conditions")
-			return 1
+		err = subscriptions.Close(ctx, *userId, !*keepData)
+		if err == nil {
+			sc.Ui.Output("Subscription closed")
 		}
-
-		method = "PUT"
-		apiEndpoint = "/subscription/applications/" + config.DhpApplicationName + "/users/" + *userId + "/close"
-		body = []byte("{\"deleteDataFlag\":\"Yes\"}")
-
 	case "tc":
-		method = "GET"
-		apiEndpoint = "/subscription/applications/" + config.DhpApplicationName + "/users/" + *userId + "/termsAndConditions"
-		queryParams = "consentCode=" + *consent + "&propositionName=" + config.PropositionName
-		if *userId == "" {
-			log.Error("user-id required to get Terms & Conditions")
+		result, err = subscriptions.TermsAndConditions(ctx, *userId, *token, *consent)
+	case "accept":
+		if *documentVersion == "" || *country == "" {
+			log.Error("version and country required to accept Terms & Conditions")
 			return 1
 		}
-		if *token != "" {
-			header.Add("accessToken", *token)
+		err = subscriptions.AcceptTermsAndConditions(ctx, *userId, *token, client.AcceptTermsRequest{
+			DocumentId:      *documentId,
+			DocumentVersion: *documentVersion,
+			ConsentCode:     *consent,
+			CountryCode:     *country,
+		})
+		if err == nil {
+			sc.Ui.Output("Terms & Conditions accepted")
 		}
+	case "list":
+		result, err = subscriptions.Subscriptions(ctx, *userId, *token)
 	default:
 		log.Error("Unknown action ", *action)
 		return 1
 	}
-	header.Add("Api-Version", "1")
-	response := c.SendSignedRequest(method, apiEndpoint, queryParams, header, body)
-	if response.StatusCode < 200 {
-		log.Print(string(response.Body))
-		for _, e := range response.Errors {
-			log.Print(e)
-		}
+	switch {
+	case errors.Is(err, client.ErrConsentRequired):
+		log.Error("The user has to accept the Terms & Conditions first, see -action=accept")
+	case errors.Is(err, client.ErrDataCleanupInProgress):
+		log.Error("Data of the user is still being deleted, try again later")
+	case errors.Is(err, client.ErrCloseWithoutDataCleanNotSupported):
+		log.Error("The application does not allow closing without deleting data, leave out -keepdata")
 	}
-	jsonParsed, err := gabs.ParseJSON([]byte(response.Body))
-	if err == nil {
-		fmt.Println(jsonParsed.StringIndent("", "  "))
-	} else {
-		log.Error(err)
+	if err != nil {
+		reportError(nil, err)
 		return 1
 	}
-
+	if result != nil {
+		output, _ := json.MarshalIndent(result, "", "  ")
+		fmt.Println(string(output))
+	}
 	return 0
 }
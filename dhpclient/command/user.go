@@ -1,16 +1,15 @@
 package command
 
 import (
-	_ "encoding/json"
+	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"git.aemian.com/dhp/client"
-	"github.com/Jeffail/gabs/v2"
-	"github.com/loafoe/cfutil"
 	"github.com/mitchellh/cli"
 	log "github.com/sirupsen/logrus"
-	"net/http"
 	"strings"
+	"time"
 )
 
 type UserCommand struct {
@@ -29,8 +28,10 @@ Options:
                     Available actions: [profile, prefs]
   -user=            The user UUID to use in actions
   -token=           A user access token
+                    Without -user the session stored by auth login is used
   -key=             The key to set
   -val=             The value of the key. If not set key will be deleted
+  -timeout=30s      Deadline for the whole call
 	`
 	return strings.TrimSpace(helpText)
 }
@@ -40,22 +41,17 @@ func (uc *UserCommand) Synopsis() string {
 }
 
 func (uc *UserCommand) Run(args []string) int {
-	var apiEndpoint string
-	var queryParams string
-	var method string
-	var header *http.Header = &http.Header{}
-
-	config := client.ApiClientConfig{
-		ApiBaseUrl:         cfutil.Getenv("DHP_AUTH_URL"),
-		DhpApplicationName: cfutil.Getenv("DHP_APPLICATION_NAME"),
-		SigningKey:         cfutil.Getenv("DHP_CUSTOMERCARE_SIGNING_KEY"),
-		SigningSecret:      cfutil.Getenv("DHP_CUSTOMERCARE_SIGNING_SECRET"),
+	config, err := serviceConfig(client.SERVICE_CUSTOMERCARE)
+	if err != nil {
+		log.Error(err)
+		return 1
 	}
 	c, err := client.NewClient(config)
 	if err != nil {
 		log.Error(err)
 		return 1
 	}
+	users := client.NewUserManagementService(c)
 
 	cmdFlags := flag.NewFlagSet("user", flag.ContinueOnError)
 	cmdFlags.Usage = func() { uc.Ui.Output(uc.Help()) }
@@ -64,75 +60,50 @@ func (uc *UserCommand) Run(args []string) int {
 	token := cmdFlags.String("token", "", "A user access token")
 	key := cmdFlags.String("key", "", "The key to set")
 	val := cmdFlags.String("val", "", "The value of the key")
+	timeout := cmdFlags.Duration("timeout", 30*time.Second, "Deadline for the whole call")
 	if err := cmdFlags.Parse(args); err != nil {
 		log.Error(err)
 		return 1
 	}
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
 
-	var body = []byte{}
+	if err := sessionDefaults(ctx, userId, token); err != nil {
+		log.Error(err)
+		return 1
+	}
 
+	var profile *client.UserProfile
 	switch *action {
 	case "profile":
-		if *userId == "" || *token == "" {
-			log.Error("user and token required to get profile")
+		if *userId == "" {
+			log.Error("user required to get profile")
 			return 1
 		}
-		method = "GET"
-		apiEndpoint = "/usermanagement/users/" + *userId + "/profile"
-		queryParams = "applicationName=" + config.DhpApplicationName
+		profile, err = users.Get(ctx, *userId, *token)
 	case "prefs":
 		if *userId == "" || *token == "" {
-			log.Error("userid, access token and key required to set prefs")
+			log.Error("userid and access token required to set prefs")
 			return 1
 		}
-		// Fetch the profile first
-		method = "GET"
-		apiEndpoint = "/usermanagement/users/" + *userId + "/profile"
-		queryParams = "applicationName=" + config.DhpApplicationName
-		header.Add("accessToken", *token)
-		response := c.SendRestRequest(method, apiEndpoint, queryParams, header, body)
-		if response.StatusCode != 200 {
-			log.Error("profile not found")
-			return 1
-		}
-		jsonParsed, _ := gabs.ParseJSON([]byte(response.Body))
-		// Tweak profile here
-		profile := jsonParsed.Path("exchange.user.profile")
 		if *key == "" {
 			// Dump the profile if no key is provided
-			fmt.Println(profile.StringIndent("", "  "))
-			return 0
+			profile, err = users.Get(ctx, *userId, *token)
+			break
 		}
-
-		if *val == "" {
-			profile.Delete("preferences", *key)
-		} else {
-			profile.Set(*val, "preferences", *key)
+		var value interface{}
+		if *val != "" {
+			value = *val
 		}
-		body = profile.Bytes()
-		method = "PUT"
+		profile, err = users.PatchPreferences(ctx, *userId, *token, map[string]interface{}{*key: value})
 	default:
 		return 1
 	}
-	var response client.Response
-	if *token == "" {
-		response = c.SendSignedRequest(method, apiEndpoint, queryParams, header, body)
-	} else {
-		header.Add("AccessToken", *token)
-		response = c.SendRestRequest(method, apiEndpoint, queryParams, header, body)
-	}
-	if response.StatusCode < 200 {
-		log.Print(string(response.Body))
-		for _, e := range response.Errors {
-			log.Print(e)
-		}
-	}
-	jsonParsed, err := gabs.ParseJSON([]byte(response.Body))
 	if err != nil {
-		log.Print(err)
+		reportError(nil, err)
 		return 1
 	}
-	fmt.Println(jsonParsed.StringIndent("", "  "))
-
+	output, _ := json.MarshalIndent(profile, "", "  ")
+	fmt.Println(string(output))
 	return 0
 }
@@ -44,6 +44,14 @@ func init() {
 				Ui:                ui,
 			}, nil
 		},
+		"config": func() (cli.Command, error) {
+			return &command.ConfigCommand{
+				Revision:          GitCommit,
+				Version:           Version,
+				VersionPrerelease: VersionPrerelease,
+				Ui:                ui,
+			}, nil
+		},
 		"cc": func() (cli.Command, error) {
 			return &command.CustomerCare{
 				Revision:          GitCommit,
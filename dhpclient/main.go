@@ -3,10 +3,12 @@ package main
 import (
 	_ "encoding/json"
 	"fmt"
+	"git.aemian.com/dhp/client/dhpclient/command"
 	"github.com/joho/godotenv"
 	"github.com/mitchellh/cli"
 	log "github.com/sirupsen/logrus"
 	"os"
+	"strings"
 )
 
 type AuthRequest struct {
@@ -14,15 +16,28 @@ type AuthRequest struct {
 	Password string `json:"password"`
 }
 
-func init() {
+// readDotenv reads $GOENV.env, or development.env when GOENV is not set.
+// The variables are applied after the profile, so a profile given on the
+// command line beats a stray dotenv file
+func readDotenv() map[string]string {
 	goEnv := os.Getenv("GOENV")
 	if goEnv != "" {
-		err := godotenv.Load(goEnv + ".env")
+		env, err := godotenv.Read(goEnv + ".env")
 		if err != nil {
 			log.Error(err)
 		}
-	} else {
-		godotenv.Load("development.env")
+		return env
+	}
+	env, _ := godotenv.Read("development.env")
+	return env
+}
+
+// setUnset sets the variables of env which are not set yet
+func setUnset(env map[string]string) {
+	for key, value := range env {
+		if _, set := os.LookupEnv(key); !set {
+			os.Setenv(key, value)
+		}
 	}
 }
 
@@ -32,7 +47,22 @@ func main() {
 
 func realMain() int {
 
-	args := os.Args[1:]
+	args, profile := globalFlags(os.Args[1:])
+	dotenv := readDotenv()
+	if profile != "" {
+		os.Setenv(command.PROFILE_ENV, profile)
+	} else if _, set := os.LookupEnv(command.PROFILE_ENV); !set && dotenv[command.PROFILE_ENV] != "" {
+		os.Setenv(command.PROFILE_ENV, dotenv[command.PROFILE_ENV])
+	}
+	// The config command manages the profiles itself, e.g. creates new ones
+	if len(args) == 0 || args[0] != "config" {
+		if err := command.ApplyProfile(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading profile: %s\n", err.Error())
+			return 1
+		}
+	}
+	setUnset(dotenv)
+
 	// Get the command line args. We shortcut "--version" and "-v" to
 	// just show the version.
 	for _, arg := range args {
@@ -62,3 +92,23 @@ func realMain() int {
 
 	return exitCode
 }
+
+// globalFlags strips the flags given before the command name,
+// of which only -profile is known, and returns the profile
+func globalFlags(args []string) ([]string, string) {
+	var profile string
+	for len(args) > 0 && strings.HasPrefix(args[0], "-") {
+		name := strings.TrimLeft(args[0], "-")
+		switch {
+		case strings.HasPrefix(name, "profile="):
+			profile = strings.TrimPrefix(name, "profile=")
+		case name == "profile" && len(args) > 1:
+			profile = args[1]
+			args = args[1:]
+		default:
+			return args, profile
+		}
+		args = args[1:]
+	}
+	return args, profile
+}
@@ -1,6 +1,7 @@
 package client
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -20,17 +21,21 @@ const (
 	RESPONSE_CODE_INVALID_USER_ID       = 1004
 	RESPONSE_CODE_ACCESS_TOKEN_REQUIRES = 1251
 	RESPONSE_CODE_VALIDATION_ERRORS     = 1254
+	RESPONSE_CODE_SIGNATURE_EXPIRED     = 3056
 )
 
 func DHPErrorResponse(errCode int, c echo.Context) {
+	c.JSON(400, newErrorResponse(errCode))
+}
+
+func newErrorResponse(errCode int) *ErrorResponse {
 	uuid, _ := uuid.V4()
 	stringErrorCode := fmt.Sprintf("%d", errCode)
-	response := &ErrorResponse{
+	return &ErrorResponse{
 		IncidentID:  uuid.String(),
 		ErrorCode:   stringErrorCode,
 		Description: StatusCodeToString(errCode),
 	}
-	c.JSON(400, response)
 }
 
 type tokenResponse struct {
@@ -59,8 +64,11 @@ func EchoAuthFilter(apiClients ...*ApiClient) echo.MiddlewareFunc {
 			}
 			t := auth[l:]
 			bearerToken := string(t)
-			ch := make(chan tokenResponse)
-			timeout := time.After(30 * time.Second) // Wait max 10 seconds
+			// Bound the fan-out by the incoming request so DHP calls are
+			// cancelled as soon as the caller goes away. Wait max 30 seconds
+			ctx, cancel := context.WithTimeout(c.Request().Context(), 30*time.Second)
+			defer cancel()
+			ch := make(chan tokenResponse, len(apiClients))
 			for _, client := range apiClients {
 				go func(apiClient *ApiClient) {
 					header := &http.Header{}
@@ -69,13 +77,12 @@ func EchoAuthFilter(apiClients ...*ApiClient) echo.MiddlewareFunc {
 					queryParams := "applicationName=" + apiClient.DHPApplicationName()
 					var body []byte
 					header.Add("AccessToken", bearerToken)
-					response := apiClient.SendSignedRequest(method, apiEndpoint, queryParams, header, body)
+					response := apiClient.SendSignedRequestWithContext(ctx, method, apiEndpoint, queryParams, header, body)
 					if response.StatusCode < 200 {
 						// TODO
 					}
 					jsonParsed, err := gabs.ParseJSON([]byte(response.Body))
 					if err != nil {
-						DHPErrorResponse(RESPONSE_CODE_VALIDATION_ERRORS, c)
 						ch <- tokenResponse{false, RESPONSE_CODE_VALIDATION_ERRORS}
 						return
 					}
@@ -114,7 +121,7 @@ func EchoAuthFilter(apiClients ...*ApiClient) echo.MiddlewareFunc {
 						DHPErrorResponse(res.reason, c)
 						return echo.ErrUnauthorized
 					}
-				case <-timeout:
+				case <-ctx.Done():
 					DHPErrorResponse(RESPONSE_CODE_GATEWAY_TIMEOUT, c)
 					return echo.ErrUnauthorized
 
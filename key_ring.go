@@ -0,0 +1,146 @@
+package client
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+// SigningCredential is a shared key together with its secret
+type SigningCredential struct {
+	SharedKey string
+	Secret    string
+}
+
+// DefaultKeyRejectedCodes are the DHP codes on which a client moves on to its
+// next signing key. Only 1264 Shared key invalid is included: DHP answers a
+// plain signature mismatch with 1266 Unauthorized access as well, in which
+// case another key does not help. Add 1266 to KeyRejectedCodes for an
+// assembly which uses it for unknown keys
+var DefaultKeyRejectedCodes = []int{RESPONSE_CODE_SHARED_KEY_INVALID}
+
+// keyRing holds the signers of a client: the primary one first, followed by
+// the fallbacks which are tried when DHP does not accept a key. New requests
+// start with the signer which was accepted last, so a rotation window only
+// costs an extra round trip once. With a CredentialsProvider the primary
+// signer is rebuilt whenever the provider hands out other credentials
+type keyRing struct {
+	config   ApiClientConfig
+	provider CredentialsProvider
+	mu       sync.RWMutex // guards signers[0]
+	signers  []ApiSigner
+	current  int32 // accessed atomically
+	rejected []int // DHP codes telling the key was refused
+}
+
+func (ring *keyRing) init(config ApiClientConfig) error {
+	ring.config = config
+	ring.provider = config.Credentials
+	ring.rejected = config.KeyRejectedCodes
+	if len(ring.rejected) == 0 {
+		ring.rejected = DefaultKeyRejectedCodes
+	}
+	primary := SigningCredential{
+		SharedKey: config.SigningKey,
+		Secret:    config.SigningSecret,
+	}
+	if ring.provider != nil {
+		credential, err := ring.provider.Credentials()
+		if err != nil {
+			return err
+		}
+		primary = credential
+	}
+	credentials := append([]SigningCredential{primary}, config.FallbackKeys...)
+	ring.signers = make([]ApiSigner, len(credentials))
+	ring.current = 0
+	for i, credential := range credentials {
+		signer, err := ring.newSigner(credential)
+		if err != nil {
+			return err
+		}
+		ring.signers[i] = signer
+	}
+	return nil
+}
+
+func (ring *keyRing) newSigner(credential SigningCredential) (ApiSigner, error) {
+	var signer ApiSigner
+	signer.Init(credential.SharedKey, credential.Secret, ring.config.Debug)
+	signer.SignHeaders(ring.config.SignedHeaders...)
+	if ring.config.SigningAlgorithm != "" {
+		if err := signer.SetAlgorithm(ring.config.SigningAlgorithm); err != nil {
+			return signer, err
+		}
+	}
+	return signer, nil
+}
+
+// signer returns the signer to use for a new request along with its index.
+// The provider is consulted on every request, also while a fallback is in
+// use, and new credentials from it make the primary signer current again.
+// When the credentials provider fails the last known primary signer is
+// returned together with the error
+func (ring *keyRing) signer() (int, *ApiSigner, error) {
+	var err error
+	if ring.provider != nil {
+		var changed bool
+		changed, err = ring.refresh()
+		if changed {
+			atomic.StoreInt32(&ring.current, 0)
+		}
+	}
+	i := int(atomic.LoadInt32(&ring.current))
+	ring.mu.RLock()
+	signer := ring.signers[i]
+	ring.mu.RUnlock()
+	return i, &signer, err
+}
+
+// refresh replaces the primary signer when the provider hands out
+// credentials other than the ones in use and reports whether it did
+func (ring *keyRing) refresh() (bool, error) {
+	credential, err := ring.provider.Credentials()
+	if err != nil {
+		return false, err
+	}
+	ring.mu.RLock()
+	current := ring.signers[0]
+	ring.mu.RUnlock()
+	if current.sharedKey == credential.SharedKey && current.secretKey == credential.Secret {
+		return false, nil
+	}
+	signer, err := ring.newSigner(credential)
+	if err != nil {
+		return false, err
+	}
+	ring.mu.Lock()
+	ring.signers[0] = signer
+	ring.mu.Unlock()
+	return true, nil
+}
+
+// rotate moves on to the signer after from, unless another
+// request already did so
+func (ring *keyRing) rotate(from int) {
+	next := (from + 1) % len(ring.signers)
+	atomic.CompareAndSwapInt32(&ring.current, int32(from), int32(next))
+}
+
+func (ring *keyRing) size() int {
+	return len(ring.signers)
+}
+
+// isKeyRejected reports whether DHP refused the signing key
+func (ring *keyRing) isKeyRejected(err error) bool {
+	var dhpErr *DHPError
+	if !errors.As(err, &dhpErr) {
+		return false
+	}
+	for _, code := range ring.rejected {
+		if dhpErr.DhpCode == code {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,149 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// switchingProvider hands out whatever credential was set last
+type switchingProvider struct {
+	mu         sync.Mutex
+	credential SigningCredential
+}
+
+func (provider *switchingProvider) Credentials() (SigningCredential, error) {
+	provider.mu.Lock()
+	defer provider.mu.Unlock()
+	return provider.credential, nil
+}
+
+func (provider *switchingProvider) set(credential SigningCredential) {
+	provider.mu.Lock()
+	provider.credential = credential
+	provider.mu.Unlock()
+}
+
+// newKeyCheckingServer verifies requests against store and answers a failed
+// verification with dhpCode. It returns the number of requests received
+func newKeyCheckingServer(store *KeyStore, dhpCode string) (*httptest.Server, func() int) {
+	var mu sync.Mutex
+	calls := 0
+	verifier := &Verifier{SecretsLookup: store.Secrets}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls++
+		mu.Unlock()
+		if _, err := verifier.Verify(r); err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"responseCode":"` + dhpCode + `"}`))
+			return
+		}
+		w.Write([]byte(`{"responseCode":"200"}`))
+	}))
+	return srv, func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return calls
+	}
+}
+
+func TestKeyRingFallsBackOnRejectedKey(t *testing.T) {
+	store := NewKeyStore(nil)
+	store.AddSecret("new", "s2", time.Time{})
+	srv, calls := newKeyCheckingServer(store, "1264")
+	defer srv.Close()
+	client, _ := NewClient(ApiClientConfig{ApiBaseUrl: srv.URL, SigningKey: "old", SigningSecret: "s1", FallbackKeys: []SigningCredential{{"new", "s2"}}})
+	if _, err := client.Do(context.Background(), "GET", "/x", "", &http.Header{}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if calls() != 2 {
+		t.Fatalf("expected the fallback to be tried, got %d calls", calls())
+	}
+	if _, err := client.Do(context.Background(), "GET", "/x", "", &http.Header{}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if calls() != 3 {
+		t.Errorf("expected the fallback to be used right away, got %d calls", calls())
+	}
+}
+
+func TestKeyRingKeepsKeyOnSignatureMismatch(t *testing.T) {
+	store := NewKeyStore(nil)
+	store.AddSecret("new", "s2", time.Time{})
+	srv, calls := newKeyCheckingServer(store, "1266")
+	defer srv.Close()
+	client, _ := NewClient(ApiClientConfig{ApiBaseUrl: srv.URL, SigningKey: "old", SigningSecret: "s1", FallbackKeys: []SigningCredential{{"new", "s2"}}})
+	if _, err := client.Do(context.Background(), "GET", "/x", "", &http.Header{}, nil); err == nil {
+		t.Fatal("expected 1266 to be returned")
+	}
+	if calls() != 1 {
+		t.Errorf("expected no fallback on 1266, got %d calls", calls())
+	}
+	if i, _, _ := client.keys.signer(); i != 0 {
+		t.Errorf("expected the primary key to stay current, got %d", i)
+	}
+}
+
+func TestKeyRingRefreshesWhileOnFallback(t *testing.T) {
+	provider := &switchingProvider{credential: SigningCredential{"old", "s1"}}
+	client, err := NewClient(ApiClientConfig{ApiBaseUrl: "http://dhp", Credentials: provider, FallbackKeys: []SigningCredential{{"fallback", "s2"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.keys.rotate(0)
+	if i, signer, _ := client.keys.signer(); i != 1 || signer.sharedKey != "fallback" {
+		t.Fatalf("expected the fallback to be current, got %d %s", i, signer.sharedKey)
+	}
+	provider.set(SigningCredential{"rotated", "s3"})
+	i, signer, err := client.keys.signer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if i != 0 || signer.sharedKey != "rotated" {
+		t.Errorf("expected the new primary key to become current, got %d %s", i, signer.sharedKey)
+	}
+}
+
+func TestKeyRingFallsBackOnConfiguredCodes(t *testing.T) {
+	store := NewKeyStore(nil)
+	store.AddSecret("new", "s2", time.Time{})
+	srv, calls := newKeyCheckingServer(store, "1266")
+	defer srv.Close()
+	client, _ := NewClient(ApiClientConfig{
+		ApiBaseUrl:       srv.URL,
+		SigningKey:       "old",
+		SigningSecret:    "s1",
+		FallbackKeys:     []SigningCredential{{"new", "s2"}},
+		KeyRejectedCodes: []int{RESPONSE_CODE_SHARED_KEY_INVALID, RESPONSE_CODE_UNAUTHORIZED_ACCESS},
+	})
+	if _, err := client.Do(context.Background(), "GET", "/x", "", &http.Header{}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if calls() != 2 {
+		t.Errorf("expected the fallback to be tried on 1266, got %d calls", calls())
+	}
+}
+
+func TestIsKeyRejected(t *testing.T) {
+	ring := &keyRing{}
+	ring.init(ApiClientConfig{})
+	for _, test := range []struct {
+		err      error
+		rejected bool
+	}{
+		{&DHPError{DhpCode: RESPONSE_CODE_SHARED_KEY_INVALID}, true},
+		{&DHPError{DhpCode: RESPONSE_CODE_UNAUTHORIZED_ACCESS}, false},
+		{&DHPError{StatusCode: http.StatusUnauthorized}, false},
+		{errors.New("network"), false},
+		{nil, false},
+	} {
+		if rejected := ring.isKeyRejected(test.err); rejected != test.rejected {
+			t.Errorf("%v: expected %v, got %v", test.err, test.rejected, rejected)
+		}
+	}
+}
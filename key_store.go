@@ -0,0 +1,86 @@
+package client
+
+import (
+	"sync"
+	"time"
+)
+
+// KeyStore holds the secrets of the callers which are allowed to send
+// signed requests, keyed by the shared key they put in Credential.
+// A shared key can have several secrets at once, each with an optional
+// expiry, so secrets can be rotated without rejecting callers which still
+// sign with the old one. It is safe for concurrent use
+type KeyStore struct {
+	mu   sync.RWMutex
+	keys map[string][]storedSecret
+}
+
+type storedSecret struct {
+	secret  string
+	expires time.Time // Zero means the secret never expires
+}
+
+func (s storedSecret) valid(now time.Time) bool {
+	return s.expires.IsZero() || now.Before(s.expires)
+}
+
+// NewKeyStore creates a KeyStore holding the given shared key to secret mapping
+func NewKeyStore(keys map[string]string) *KeyStore {
+	store := &KeyStore{keys: make(map[string][]storedSecret)}
+	for sharedKey, secretKey := range keys {
+		store.Add(sharedKey, secretKey)
+	}
+	return store
+}
+
+// Add registers the secret of a shared key, replacing any existing ones
+func (store *KeyStore) Add(sharedKey, secretKey string) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	store.keys[sharedKey] = []storedSecret{{secret: secretKey}}
+}
+
+// AddSecret adds a secret to a shared key next to its existing ones. The
+// secret is no longer accepted after expires, a zero expires keeps it forever.
+// The most recently added secret is tried first
+func (store *KeyStore) AddSecret(sharedKey, secretKey string, expires time.Time) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	secrets := []storedSecret{{secret: secretKey, expires: expires}}
+	for _, s := range store.keys[sharedKey] {
+		if s.secret != secretKey {
+			secrets = append(secrets, s)
+		}
+	}
+	store.keys[sharedKey] = secrets
+}
+
+// Remove revokes a shared key and all of its secrets
+func (store *KeyStore) Remove(sharedKey string) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	delete(store.keys, sharedKey)
+}
+
+// Lookup implements KeyLookup, returning the most recent valid secret
+func (store *KeyStore) Lookup(sharedKey string) (string, bool) {
+	secrets := store.Secrets(sharedKey)
+	if len(secrets) == 0 {
+		return "", false
+	}
+	return secrets[0], true
+}
+
+// Secrets implements SecretsLookup, returning all valid secrets of a shared key
+func (store *KeyStore) Secrets(sharedKey string) []string {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+	now := time.Now()
+	var secrets []string
+	for _, s := range store.keys[sharedKey] {
+		if s.valid(now) {
+			secrets = append(secrets, s.secret)
+		}
+	}
+	return secrets
+}
@@ -0,0 +1,218 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	RESPONSE_CODE_OBS_TRANSACTION_NOT_AVAILABLE  = 1501
+	RESPONSE_CODE_OBS_INVALID_APPLICATION        = 1507
+	RESPONSE_CODE_OBS_INVALID_PROPOSITION        = 1508
+	RESPONSE_CODE_OBS_INVALID_FEED_VENDOR        = 1509
+	RESPONSE_CODE_OBS_INVALID_SUBSCRIPTION       = 1510
+	RESPONSE_CODE_OBS_INVALID_TERMS              = 1511
+	RESPONSE_CODE_OBS_DATA_TYPE_REQUIRED         = 1512
+	RESPONSE_CODE_OBS_TIMESTAMP_REQUIRED         = 1513
+	RESPONSE_CODE_OBS_DATA_REQUIRED              = 1515
+	RESPONSE_CODE_OBS_TRANSACTION_SERVICE_FAILED = 1517
+	RESPONSE_CODE_OBS_INVALID_TRACKER_API        = 1521
+	RESPONSE_CODE_OBS_INVALID_SUBSCRIPTION_API   = 1522
+	RESPONSE_CODE_OBS_TIMESTAMP_EMPTY            = 1524
+	RESPONSE_CODE_OBS_DATA_EMPTY                 = 1526
+	RESPONSE_CODE_OBS_TIMESTAMP_INVALID          = 1528
+	RESPONSE_CODE_OBS_SIGNATURE_FAILED           = 1532
+	RESPONSE_CODE_OBS_SUBSCRIPTIONS_URL_MISSING  = 1533
+	RESPONSE_CODE_OBS_TERMS_URL_MISSING          = 1534
+	RESPONSE_CODE_OBS_NO_SUBSCRIPTIONS           = 1535
+	RESPONSE_CODE_OBS_DELETE_NOT_ALLOWED         = 1536
+	RESPONSE_CODE_OBS_INVALID_MODE               = 1537
+	RESPONSE_CODE_OBS_NORMALIZATION_URL_MISSING  = 1539
+	RESPONSE_CODE_OBS_INVALID_NORMALIZATION_URL  = 1540
+	RESPONSE_CODE_OBS_NORMALIZATION_FAILED       = 1541
+	RESPONSE_CODE_OBS_DELETE_FAILED              = 1542
+	RESPONSE_CODE_OBS_DATABASE_ERROR             = 1543
+)
+
+// Observation Storage Service errors to use with errors.Is
+var (
+	ErrTransactionNotAvailable            = &DHPError{DhpCode: RESPONSE_CODE_OBS_TRANSACTION_NOT_AVAILABLE}
+	ErrObservationInvalidApplication      = &DHPError{DhpCode: RESPONSE_CODE_OBS_INVALID_APPLICATION}
+	ErrObservationInvalidProposition      = &DHPError{DhpCode: RESPONSE_CODE_OBS_INVALID_PROPOSITION}
+	ErrObservationInvalidFeedVendor       = &DHPError{DhpCode: RESPONSE_CODE_OBS_INVALID_FEED_VENDOR}
+	ErrObservationInvalidSubscription     = &DHPError{DhpCode: RESPONSE_CODE_OBS_INVALID_SUBSCRIPTION}
+	ErrObservationInvalidTerms            = &DHPError{DhpCode: RESPONSE_CODE_OBS_INVALID_TERMS}
+	ErrObservationDataTypeRequired        = &DHPError{DhpCode: RESPONSE_CODE_OBS_DATA_TYPE_REQUIRED}
+	ErrObservationTimestampRequired       = &DHPError{DhpCode: RESPONSE_CODE_OBS_TIMESTAMP_REQUIRED}
+	ErrObservationDataRequired            = &DHPError{DhpCode: RESPONSE_CODE_OBS_DATA_REQUIRED}
+	ErrTransactionServiceFailed           = &DHPError{DhpCode: RESPONSE_CODE_OBS_TRANSACTION_SERVICE_FAILED}
+	ErrObservationInvalidTrackerAPI       = &DHPError{DhpCode: RESPONSE_CODE_OBS_INVALID_TRACKER_API}
+	ErrObservationInvalidSubscriptionAPI  = &DHPError{DhpCode: RESPONSE_CODE_OBS_INVALID_SUBSCRIPTION_API}
+	ErrObservationTimestampEmpty          = &DHPError{DhpCode: RESPONSE_CODE_OBS_TIMESTAMP_EMPTY}
+	ErrObservationDataEmpty               = &DHPError{DhpCode: RESPONSE_CODE_OBS_DATA_EMPTY}
+	ErrObservationTimestampInvalid        = &DHPError{DhpCode: RESPONSE_CODE_OBS_TIMESTAMP_INVALID}
+	ErrObservationSignatureFailed         = &DHPError{DhpCode: RESPONSE_CODE_OBS_SIGNATURE_FAILED}
+	ErrObservationSubscriptionsURLMissing = &DHPError{DhpCode: RESPONSE_CODE_OBS_SUBSCRIPTIONS_URL_MISSING}
+	ErrObservationTermsURLMissing         = &DHPError{DhpCode: RESPONSE_CODE_OBS_TERMS_URL_MISSING}
+	ErrObservationNoSubscriptions         = &DHPError{DhpCode: RESPONSE_CODE_OBS_NO_SUBSCRIPTIONS}
+	ErrTransactionDeleteNotAllowed        = &DHPError{DhpCode: RESPONSE_CODE_OBS_DELETE_NOT_ALLOWED}
+	ErrObservationInvalidMode             = &DHPError{DhpCode: RESPONSE_CODE_OBS_INVALID_MODE}
+	ErrNormalizationURLMissing            = &DHPError{DhpCode: RESPONSE_CODE_OBS_NORMALIZATION_URL_MISSING}
+	ErrInvalidNormalizationURL            = &DHPError{DhpCode: RESPONSE_CODE_OBS_INVALID_NORMALIZATION_URL}
+	ErrNormalizationFailed                = &DHPError{DhpCode: RESPONSE_CODE_OBS_NORMALIZATION_FAILED}
+	ErrTransactionDeleteFailed            = &DHPError{DhpCode: RESPONSE_CODE_OBS_DELETE_FAILED}
+	ErrObservationDatabaseError           = &DHPError{DhpCode: RESPONSE_CODE_OBS_DATABASE_ERROR}
+)
+
+// IsObservationError reports whether err is one of the
+// Observation Storage Service errors 1501 to 1543
+func IsObservationError(err error) bool {
+	var dhpErr *DHPError
+	return errors.As(err, &dhpErr) &&
+		dhpErr.DhpCode >= RESPONSE_CODE_OBS_TRANSACTION_NOT_AVAILABLE &&
+		dhpErr.DhpCode <= RESPONSE_CODE_OBS_DATABASE_ERROR
+}
+
+// TransactionState is the processing state of an observation upload
+type TransactionState string
+
+const (
+	TRANSACTION_STATE_RECEIVED    TransactionState = "STATE_RECEIVED"
+	TRANSACTION_STATE_IN_PROGRESS TransactionState = "STATE_IN_PROGRESS"
+	TRANSACTION_STATE_COMPLETED   TransactionState = "STATE_COMPLETED"
+	TRANSACTION_STATE_FAILED      TransactionState = "STATE_FAILED"
+)
+
+// Terminal reports whether processing has finished, successfully or not.
+// Only transactions in a terminal state can be deleted
+func (state TransactionState) Terminal() bool {
+	return state == TRANSACTION_STATE_COMPLETED || state == TRANSACTION_STATE_FAILED
+}
+
+// Observation is a single measurement
+type Observation struct {
+	DataType  string      // The standard observation type, e.g. weight
+	TimeStamp time.Time   // When the observation was made
+	Data      interface{} // The observation itself, encoded as JSON
+}
+
+// MarshalJSON implements json.Marshaler
+func (observation Observation) MarshalJSON() ([]byte, error) {
+	timeStamp := ""
+	if !observation.TimeStamp.IsZero() {
+		timeStamp = observation.TimeStamp.Format(TIME_FORMAT)
+	}
+	return json.Marshal(&struct {
+		DataType  string      `json:"dataType"`
+		TimeStamp string      `json:"timeStamp"`
+		Data      interface{} `json:"data"`
+	}{observation.DataType, timeStamp, observation.Data})
+}
+
+// UploadRequest stores observations of a user
+type UploadRequest struct {
+	UserUUID        string
+	AccessToken     string // Optional, without it the request is only signed
+	PropositionName string // Defaults to the one of the client configuration
+	FeedVendorName  string // The source of the observations
+	Mode            string // Optional processing mode
+	Observations    []Observation
+}
+
+// Transaction is the processing status of an observation upload
+type Transaction struct {
+	TransactionId string           `json:"transactionId"`
+	State         TransactionState `json:"state"`
+	Message       string           `json:"message,omitempty"`
+}
+
+// ObservationService stores observations through the DHP Observation Storage
+// Service. Uploads are processed asynchronously: Upload returns a transaction
+// id which can be followed with Transaction. Failures matching the 1501 to
+// 1543 codes are returned as *DHPError, see IsObservationError
+type ObservationService struct {
+	client     *ApiClient
+	ApiVersion string // Optional Api-Version header
+}
+
+// NewObservationService returns a service using the given client, which
+// must be configured with DHP_OBSERVATION_SERVICE_URL and signing keys
+func NewObservationService(client *ApiClient) *ObservationService {
+	return &ObservationService{client: client}
+}
+
+// Upload stores observations and returns the id of the transaction processing them
+func (s *ObservationService) Upload(ctx context.Context, request UploadRequest) (string, error) {
+	proposition := request.PropositionName
+	if proposition == "" {
+		proposition = s.client.config.PropositionName
+	}
+	query := url.Values{}
+	query.Set("feedVendorName", request.FeedVendorName)
+	if request.Mode != "" {
+		query.Set("mode", request.Mode)
+	}
+	body, err := json.Marshal(request.Observations)
+	if err != nil {
+		return "", err
+	}
+	apiEndpoint := "/observationstorage/applications/" + url.PathEscape(s.client.DHPApplicationName()) +
+		"/propositions/" + url.PathEscape(proposition) + "/users/" + url.PathEscape(request.UserUUID) + "/observations"
+	response, err := s.client.Do(ctx, "POST", apiEndpoint, query.Encode(), s.header(request.AccessToken), body)
+	if err != nil {
+		return "", err
+	}
+	var transactionId string
+	if _, err := response.decodeExchange(&transactionId, "exchange.transactionId", "transactionId"); err != nil {
+		return "", err
+	}
+	if transactionId == "" {
+		return "", errors.New("dhp: no transaction id in response")
+	}
+	return transactionId, nil
+}
+
+// Transaction fetches the processing status of an upload
+func (s *ObservationService) Transaction(ctx context.Context, userUUID, accessToken, transactionId string) (*Transaction, error) {
+	response, err := s.client.Do(ctx, "GET", s.transactionEndpoint(userUUID, transactionId), "", s.header(accessToken), nil)
+	if err != nil {
+		return nil, err
+	}
+	transaction := &Transaction{TransactionId: transactionId}
+	found, err := response.decodeExchange(transaction, "exchange.transaction", "exchange")
+	if err != nil {
+		return nil, err
+	}
+	if !found || transaction.State == "" {
+		return nil, errors.New("dhp: no transaction state in response")
+	}
+	return transaction, nil
+}
+
+// DeleteTransaction removes an upload and its observations. Only completed
+// or failed transactions can be deleted, others fail with
+// ErrTransactionDeleteNotAllowed
+func (s *ObservationService) DeleteTransaction(ctx context.Context, userUUID, accessToken, transactionId string) error {
+	_, err := s.client.Do(ctx, "DELETE", s.transactionEndpoint(userUUID, transactionId), "", s.header(accessToken), nil)
+	return err
+}
+
+func (s *ObservationService) transactionEndpoint(userUUID, transactionId string) string {
+	return "/observationstorage/applications/" + url.PathEscape(s.client.DHPApplicationName()) +
+		"/users/" + url.PathEscape(userUUID) + "/transactions/" + url.PathEscape(transactionId)
+}
+
+func (s *ObservationService) header(accessToken string) *http.Header {
+	header := &http.Header{}
+	if s.ApiVersion != "" {
+		header.Set("Api-Version", s.ApiVersion)
+	}
+	if accessToken != "" {
+		header.Set("accessToken", accessToken)
+	}
+	return header
+}
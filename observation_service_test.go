@@ -0,0 +1,125 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestObservationServiceEscapesPathOnce(t *testing.T) {
+	client, requests := newServiceServer(t, `{"responseCode":"200","exchange":{"transactionId":"tx 1","state":"STATE_COMPLETED"}}`)
+	service := NewObservationService(client)
+	ctx := context.Background()
+	transactionId, err := service.Upload(ctx, UploadRequest{
+		UserUUID:        "user uuid",
+		PropositionName: "my prop",
+		FeedVendorName:  "vendor",
+		Observations:    []Observation{{DataType: "weight", TimeStamp: time.Unix(0, 0).UTC(), Data: map[string]float64{"value": 75.5}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	transaction, err := service.Transaction(ctx, "user uuid", "", transactionId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transaction.TransactionId != "tx 1" || !transaction.State.Terminal() {
+		t.Errorf("unexpected transaction %+v", transaction)
+	}
+	if err := service.DeleteTransaction(ctx, "user uuid", "", transactionId); err != nil {
+		t.Fatal(err)
+	}
+	for i, path := range []string{
+		"/observationstorage/applications/my%20app/propositions/my%20prop/users/user%20uuid/observations",
+		"/observationstorage/applications/my%20app/users/user%20uuid/transactions/tx%201",
+		"/observationstorage/applications/my%20app/users/user%20uuid/transactions/tx%201",
+	} {
+		request := requests()[i]
+		if request.VerifyErr != nil {
+			t.Errorf("%s %s: %v", request.Method, request.Path, request.VerifyErr)
+		}
+		if request.EscapedPath != path {
+			t.Errorf("expected %s, got %s", path, request.EscapedPath)
+		}
+	}
+}
+
+func TestObservationServiceEscapesIds(t *testing.T) {
+	client, requests := newServiceServer(t, `{"responseCode":"200","exchange":{"transactionId":"a/b","state":"STATE_COMPLETED"}}`)
+	service := NewObservationService(client)
+	if _, err := service.Transaction(context.Background(), "user/uuid", "", "tx?1"); err != nil {
+		t.Fatal(err)
+	}
+	request := requests()[0]
+	if request.VerifyErr != nil {
+		t.Error(request.VerifyErr)
+	}
+	if path := "/observationstorage/applications/my%20app/users/user%2Fuuid/transactions/tx%3F1"; request.EscapedPath != path {
+		t.Errorf("expected %s, got %s", path, request.EscapedPath)
+	}
+}
+
+func TestObservationServiceTransactionStates(t *testing.T) {
+	for _, test := range []struct {
+		body     string
+		expected Transaction
+		fails    string
+	}{
+		{
+			body:     `{"responseCode":"200","exchange":{"transaction":{"transactionId":"tx","state":"STATE_FAILED","message":"bad data"}}}`,
+			expected: Transaction{TransactionId: "tx", State: TRANSACTION_STATE_FAILED, Message: "bad data"},
+		},
+		{
+			body:     `{"responseCode":"200","exchange":{"state":"STATE_IN_PROGRESS"}}`,
+			expected: Transaction{TransactionId: "tx", State: TRANSACTION_STATE_IN_PROGRESS},
+		},
+		{body: `{"responseCode":"200","exchange":{"transactionId":"tx"}}`, fails: "no transaction state in response"},
+		{body: `{"responseCode":"200"}`, fails: "no transaction state in response"},
+	} {
+		client, _ := newServiceServer(t, test.body)
+		transaction, err := NewObservationService(client).Transaction(context.Background(), "uuid", "", "tx")
+		if test.fails != "" {
+			if err == nil || !strings.Contains(err.Error(), test.fails) {
+				t.Errorf("%s: expected %q, got %v", test.body, test.fails, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", test.body, err)
+		} else if *transaction != test.expected {
+			t.Errorf("%s: expected %+v, got %+v", test.body, test.expected, *transaction)
+		}
+	}
+}
+
+func TestObservationServiceDeleteNotAllowed(t *testing.T) {
+	client, _ := newServiceServer(t, `{"responseCode":"1536"}`)
+	err := NewObservationService(client).DeleteTransaction(context.Background(), "uuid", "", "tx")
+	if !errors.Is(err, ErrTransactionDeleteNotAllowed) {
+		t.Errorf("expected ErrTransactionDeleteNotAllowed, got %v", err)
+	}
+	if !IsObservationError(err) {
+		t.Errorf("expected %v to be an observation error", err)
+	}
+}
+
+func TestIsObservationError(t *testing.T) {
+	for _, test := range []struct {
+		err      error
+		expected bool
+	}{
+		{&DHPError{DhpCode: RESPONSE_CODE_OBS_TRANSACTION_NOT_AVAILABLE}, true},
+		{&DHPError{DhpCode: RESPONSE_CODE_OBS_DELETE_NOT_ALLOWED}, true},
+		{ErrInvalidNormalizationURL, true},
+		{ErrAccessTokenExpired, false},
+		{&DHPError{StatusCode: 500}, false},
+		{errors.New("dhp: 1536"), false},
+		{nil, false},
+	} {
+		if IsObservationError(test.err) != test.expected {
+			t.Errorf("%v: expected %v", test.err, test.expected)
+		}
+	}
+}
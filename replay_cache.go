@@ -0,0 +1,79 @@
+package client
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// ReplayCache remembers the signatures of verified requests so a captured
+// request cannot be sent again while its SignedDate is still valid
+type ReplayCache interface {
+	// Seen records key until expiry and reports whether it was
+	// already recorded and not yet expired
+	Seen(key string, expiry time.Time) bool
+}
+
+// DefaultReplayCacheSize is the capacity used by NewMemoryReplayCache
+// when it is given no positive size
+const DefaultReplayCacheSize = 100000
+
+// MemoryReplayCache is an in-memory LRU ReplayCache with a bounded number
+// of entries. When it is full the least recently seen signature is evicted,
+// so size it for the expected request rate times the signature validity
+type MemoryReplayCache struct {
+	mu      sync.Mutex
+	size    int
+	entries map[string]*list.Element
+	order   *list.List
+}
+
+type replayEntry struct {
+	key    string
+	expiry time.Time
+}
+
+// NewMemoryReplayCache creates a MemoryReplayCache holding at most size entries
+func NewMemoryReplayCache(size int) *MemoryReplayCache {
+	if size <= 0 {
+		size = DefaultReplayCacheSize
+	}
+	return &MemoryReplayCache{
+		size:    size,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// Seen implements ReplayCache
+func (cache *MemoryReplayCache) Seen(key string, expiry time.Time) bool {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	now := time.Now()
+	if element, ok := cache.entries[key]; ok {
+		entry := element.Value.(*replayEntry)
+		if now.Before(entry.expiry) {
+			cache.order.MoveToFront(element)
+			return true
+		}
+		entry.expiry = expiry
+		cache.order.MoveToFront(element)
+		return false
+	}
+	cache.entries[key] = cache.order.PushFront(&replayEntry{key: key, expiry: expiry})
+	cache.prune(now)
+	return false
+}
+
+// prune drops expired entries from the back and evicts the least recently
+// seen ones while the cache is over capacity
+func (cache *MemoryReplayCache) prune(now time.Time) {
+	for element := cache.order.Back(); element != nil; element = cache.order.Back() {
+		entry := element.Value.(*replayEntry)
+		if cache.order.Len() <= cache.size && now.Before(entry.expiry) {
+			return
+		}
+		cache.order.Remove(element)
+		delete(cache.entries, entry.key)
+	}
+}
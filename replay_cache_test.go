@@ -0,0 +1,81 @@
+package client
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestReplayCacheSeen(t *testing.T) {
+	cache := NewMemoryReplayCache(10)
+	expiry := time.Now().Add(time.Minute)
+	if cache.Seen("a", expiry) {
+		t.Fatal("expected a new key not to be seen")
+	}
+	if !cache.Seen("a", expiry) {
+		t.Fatal("expected a recorded key to be seen")
+	}
+}
+
+func TestReplayCacheExpiry(t *testing.T) {
+	cache := NewMemoryReplayCache(10)
+	if cache.Seen("a", time.Now().Add(-time.Second)) {
+		t.Fatal("expected a new key not to be seen")
+	}
+	if cache.Seen("a", time.Now().Add(time.Minute)) {
+		t.Fatal("expected an expired key not to be seen")
+	}
+	if !cache.Seen("a", time.Now().Add(time.Minute)) {
+		t.Fatal("expected the key to be recorded again")
+	}
+}
+
+func TestReplayCacheEvictsLeastRecentlySeen(t *testing.T) {
+	cache := NewMemoryReplayCache(2)
+	expiry := time.Now().Add(time.Minute)
+	cache.Seen("a", expiry)
+	cache.Seen("b", expiry)
+	cache.Seen("a", expiry) // a is now the most recently seen
+	cache.Seen("c", expiry) // evicts b
+	if cache.order.Len() != 2 {
+		t.Fatalf("expected 2 entries, got %d", cache.order.Len())
+	}
+	if !cache.Seen("a", expiry) {
+		t.Error("expected a to be kept")
+	}
+	if cache.Seen("b", expiry) {
+		t.Error("expected b to be evicted")
+	}
+}
+
+func TestReplayCacheDefaultSize(t *testing.T) {
+	if cache := NewMemoryReplayCache(0); cache.size != DefaultReplayCacheSize {
+		t.Errorf("expected size %d, got %d", DefaultReplayCacheSize, cache.size)
+	}
+}
+
+func TestReplayCacheConcurrentUse(t *testing.T) {
+	cache := NewMemoryReplayCache(1000)
+	expiry := time.Now().Add(time.Minute)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	seen := 0
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if cache.Seen(fmt.Sprint(j), expiry) {
+					mu.Lock()
+					seen++
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if seen != 700 {
+		t.Errorf("expected every key to be new exactly once, got %d repeats", seen)
+	}
+}
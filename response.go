@@ -1,7 +1,10 @@
 package client
 
 import (
+	"encoding/json"
 	"net/http"
+
+	"github.com/Jeffail/gabs/v2"
 )
 
 // Response is returned as the result of DHP request done with SendRestRequest() or SendSignedRequest()
@@ -11,5 +14,33 @@ type Response struct {
 	DhpCode    int            // The DHP response code
 	Body       string         // The full body of the response
 	Response   *http.Response // Useful when you need a http.Response representation
-	Errors     []error        // Slice of identified errors in the response
+	Errors     []error        // Slice of identified errors in the response, see FieldError
+}
+
+// FieldErrors returns the per-field validation failures found in the response
+func (r *Response) FieldErrors() []*FieldError {
+	var fieldErrors []*FieldError
+	for _, e := range r.Errors {
+		if fe, ok := e.(*FieldError); ok {
+			fieldErrors = append(fieldErrors, fe)
+		}
+	}
+	return fieldErrors
+}
+
+// decodeExchange decodes the first of the given paths found in the body
+// into v. It returns false when none of them is present
+func (r *Response) decodeExchange(v interface{}, paths ...string) (bool, error) {
+	jsonParsed, err := gabs.ParseJSON([]byte(r.Body))
+	if err != nil {
+		return false, err
+	}
+	for _, path := range paths {
+		container := jsonParsed.Path(path)
+		if container.Data() == nil {
+			continue
+		}
+		return true, json.Unmarshal(container.Bytes(), v)
+	}
+	return false, nil
 }
@@ -0,0 +1,53 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/labstack/echo"
+	log "github.com/sirupsen/logrus"
+)
+
+type credentialContextKey struct{}
+
+// CredentialFromContext returns the shared key of the caller authenticated
+// by SignatureFilter or EchoSignatureFilter
+func CredentialFromContext(ctx context.Context) (string, bool) {
+	credential, ok := ctx.Value(credentialContextKey{}).(string)
+	return credential, ok
+}
+
+// SignatureFilter returns net/http middleware which only lets requests
+// through that carry a valid DHP signature. Rejected requests get a 401 with
+// a DHP style error body. The request context of accepted requests carries
+// the shared key of the caller, see CredentialFromContext
+func SignatureFilter(verifier *Verifier) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			auth, err := verifier.Verify(r)
+			if err != nil {
+				errCode := RESPONSE_CODE_SIGNATURE_FAILED
+				if verr, ok := err.(*VerificationError); ok {
+					errCode = verr.DhpCode
+				}
+				log.Debug("Rejected signed request: ", err)
+				writeErrorResponse(w, http.StatusUnauthorized, errCode)
+				return
+			}
+			ctx := context.WithValue(r.Context(), credentialContextKey{}, auth.Credential)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// EchoSignatureFilter is the echo flavour of SignatureFilter
+func EchoSignatureFilter(verifier *Verifier) echo.MiddlewareFunc {
+	return echo.WrapMiddleware(SignatureFilter(verifier))
+}
+
+func writeErrorResponse(w http.ResponseWriter, status, errCode int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(newErrorResponse(errCode))
+}
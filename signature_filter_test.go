@@ -0,0 +1,81 @@
+package client
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/labstack/echo"
+)
+
+func testVerifier() *Verifier {
+	return &Verifier{KeyLookup: testKeyLookup}
+}
+
+// credentialHandler answers with the credential found in the request context
+var credentialHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	credential, ok := CredentialFromContext(r.Context())
+	if !ok {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Write([]byte(credential))
+})
+
+func expectRejected(t *testing.T, recorder *httptest.ResponseRecorder, dhpCode int) {
+	t.Helper()
+	if recorder.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", recorder.Code)
+	}
+	var errorResponse ErrorResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &errorResponse); err != nil {
+		t.Fatalf("expected an ErrorResponse body, got %s", recorder.Body)
+	}
+	if errorResponse.ErrorCode != strconv.Itoa(dhpCode) || errorResponse.Description != StatusCodeToString(dhpCode) {
+		t.Errorf("expected error code %d, got %+v", dhpCode, errorResponse)
+	}
+}
+
+func TestSignatureFilterRejects(t *testing.T) {
+	handler := SignatureFilter(testVerifier())(credentialHandler)
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/x", nil))
+	expectRejected(t, recorder, RESPONSE_CODE_AUTHORIZATION_MISSING)
+
+	req := signedRequest(t, "GET", "/x", "", http.Header{}, nil)
+	req.URL.Path = "/y"
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	expectRejected(t, recorder, RESPONSE_CODE_UNAUTHORIZED_ACCESS)
+}
+
+func TestSignatureFilterAccepts(t *testing.T) {
+	handler := SignatureFilter(testVerifier())(credentialHandler)
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, signedRequest(t, "GET", "/x", "", http.Header{}, nil))
+	if recorder.Code != http.StatusOK || recorder.Body.String() != "key" {
+		t.Errorf("expected the credential key, got %d %s", recorder.Code, recorder.Body)
+	}
+	if _, ok := CredentialFromContext(httptest.NewRequest("GET", "/x", nil).Context()); ok {
+		t.Error("expected no credential outside the filter")
+	}
+}
+
+func TestEchoSignatureFilter(t *testing.T) {
+	e := echo.New()
+	e.Use(EchoSignatureFilter(testVerifier()))
+	e.GET("/x", echo.WrapHandler(credentialHandler))
+
+	recorder := httptest.NewRecorder()
+	e.ServeHTTP(recorder, httptest.NewRequest("GET", "/x", nil))
+	expectRejected(t, recorder, RESPONSE_CODE_AUTHORIZATION_MISSING)
+
+	recorder = httptest.NewRecorder()
+	e.ServeHTTP(recorder, signedRequest(t, "GET", "/x", "", http.Header{}, nil))
+	if recorder.Code != http.StatusOK || recorder.Body.String() != "key" {
+		t.Errorf("expected the credential key, got %d %s", recorder.Code, recorder.Body)
+	}
+}
@@ -3,6 +3,7 @@ package command
 import (
 	_ "encoding/json"
 	"flag"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"os"
@@ -33,6 +34,8 @@ Options:
   -path=                 Path of the request e.g. /api/do/something
   -api=                  Optional Api-Version to send
   -params=               Param list
+  -trace=                Optionally explain the signature: text or json
+  -algorithm=            Signing algorithm, defaults to HmacSHA256
 	`
 	return strings.TrimSpace(helpText)
 }
@@ -44,23 +47,6 @@ func (sr *SignCommand) Synopsis() string {
 func (sr *SignCommand) Run(args []string) int {
 	var header *http.Header = &http.Header{}
 
-	signingKey := cfutil.Getenv("DHP_CUSTOMERCARE_SIGNING_KEY")
-	signingSecret := cfutil.Getenv("DHP_CUSTOMERCARE_SIGNING_SECRET")
-
-	// Setup configuration for the apiClient
-	config := client.ApiClientConfig{
-		ApiBaseUrl:         "http://dummy-host",
-		DhpApplicationName: "dummyName",
-		SigningKey:         signingKey,
-		SigningSecret:      signingSecret,
-		Debug:              os.Getenv("DHP_CLIENT_DEBUG") == "true",
-	}
-	apiClient, err := client.NewClient(config)
-	if err != nil {
-		log.Error(err)
-		return 1
-	}
-
 	// Setup and parse parameters
 	cmdFlags := flag.NewFlagSet("sign", flag.ContinueOnError)
 	cmdFlags.Usage = func() { sr.Ui.Output(sr.Help()) }
@@ -70,11 +56,29 @@ func (sr *SignCommand) Run(args []string) int {
 	bodyFile := cmdFlags.String("body", "", "The request body or path to file which contains request body")
 	path := cmdFlags.String("path", "", "The request path e.g. /api/do/something")
 	api := cmdFlags.String("api", "", "Optional Api-version to send in Api-Header")
+	traceFormat := cmdFlags.String("trace", "", "Optionally explain the signature: text or json")
+	algorithm := cmdFlags.String("algorithm", client.ALGORITHM_NAME, "Signing algorithm")
 
 	if err := cmdFlags.Parse(args); err != nil {
 		log.Error(err)
 		return 1
 	}
+
+	// Setup configuration for the apiClient
+	config, err := client.LoadServiceConfig(client.SERVICE_CUSTOMERCARE, cfutil.Getenv(client.SERVICE_BINDING_ENV))
+	if err != nil {
+		log.Error(err)
+		return 1
+	}
+	config.ApiBaseUrl = "http://dummy-host"
+	config.DhpApplicationName = "dummyName"
+	config.Debug = os.Getenv("DHP_CLIENT_DEBUG") == "true"
+	config.SigningAlgorithm = *algorithm
+	apiClient, err := client.NewClient(config)
+	if err != nil {
+		log.Error(err)
+		return 1
+	}
 	if *path == "" {
 		log.Error("path must be provided")
 		return 1
@@ -92,9 +96,8 @@ func (sr *SignCommand) Run(args []string) int {
 		header.Set("Api-Version", *api)
 	}
 
-	apiClient.Sign(time.Now(), header, *path, *params, *method, body)
-	log.Info("Key    = ", signingKey)
-	log.Info("Secret = ", signingSecret)
+	trace := apiClient.SignWithTrace(time.Now(), header, *path, *params, *method, body)
+	log.Info("Key    = ", config.SigningKey)
 	log.Info("Path   = ", *path)
 	log.Info("Params = ", *params)
 	log.Info("Method = ", *method)
@@ -103,5 +106,20 @@ func (sr *SignCommand) Run(args []string) int {
 	log.Info("------------ Signed result -------------")
 	log.Info("SignedDate: ", header.Get("SignedDate"))
 	log.Info("Authorization: ", header.Get("Authorization"))
+	switch *traceFormat {
+	case "":
+	case "text":
+		fmt.Println(trace.String())
+	case "json":
+		output, err := trace.JSON()
+		if err != nil {
+			log.Error(err)
+			return 1
+		}
+		fmt.Println(string(output))
+	default:
+		log.Error("Unknown trace format ", *traceFormat)
+		return 1
+	}
 	return 0
 }
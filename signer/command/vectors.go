@@ -0,0 +1,75 @@
+package command
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"git.aemian.com/dhp/client"
+	"github.com/mitchellh/cli"
+	log "github.com/sirupsen/logrus"
+)
+
+type VectorsCommand struct {
+	Revision          string
+	Version           string
+	VersionPrerelease string
+	Ui                cli.Ui
+}
+
+func (vc *VectorsCommand) Help() string {
+	helpText := `
+Usage: signer vectors [options]
+  Emit the signing conformance suite as JSON, or check a suite file
+  against this implementation
+Options:
+  -check=                Path of a conformance suite file to check
+	`
+	return strings.TrimSpace(helpText)
+}
+
+func (vc *VectorsCommand) Synopsis() string {
+	return "Emit or check signing conformance vectors"
+}
+
+func (vc *VectorsCommand) Run(args []string) int {
+	cmdFlags := flag.NewFlagSet("vectors", flag.ContinueOnError)
+	cmdFlags.Usage = func() { vc.Ui.Output(vc.Help()) }
+	check := cmdFlags.String("check", "", "Path of a conformance suite file to check")
+	if err := cmdFlags.Parse(args); err != nil {
+		log.Error(err)
+		return 1
+	}
+
+	if *check == "" {
+		output, err := json.MarshalIndent(client.NewConformanceSuite(), "", "  ")
+		if err != nil {
+			log.Error(err)
+			return 1
+		}
+		fmt.Println(string(output))
+		return 0
+	}
+
+	data, err := ioutil.ReadFile(*check)
+	if err != nil {
+		log.Error(err)
+		return 1
+	}
+	var suite client.ConformanceSuite
+	if err := json.Unmarshal(data, &suite); err != nil {
+		log.Error(err)
+		return 1
+	}
+	errs := suite.Check()
+	for _, e := range errs {
+		log.Error(e)
+	}
+	if len(errs) > 0 {
+		return 1
+	}
+	log.Info(len(suite.Vectors), " vectors OK")
+	return 0
+}
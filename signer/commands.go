@@ -20,5 +20,13 @@ func init() {
 				Ui:                ui,
 			}, nil
 		},
+		"vectors": func() (cli.Command, error) {
+			return &command.VectorsCommand{
+				Revision:          GitCommit,
+				Version:           Version,
+				VersionPrerelease: VersionPrerelease,
+				Ui:                ui,
+			}, nil
+		},
 	}
 }
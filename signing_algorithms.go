@@ -0,0 +1,51 @@
+package client
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+	"hash"
+	"sort"
+)
+
+// The supported signing algorithms. ALGORITHM_NAME is the default
+const (
+	ALGORITHM_HMAC_SHA256 = "HmacSHA256"
+	ALGORITHM_HMAC_SHA384 = "HmacSHA384"
+	ALGORITHM_HMAC_SHA512 = "HmacSHA512"
+)
+
+var signingAlgorithms = map[string]func() hash.Hash{
+	ALGORITHM_HMAC_SHA256: sha256.New,
+	ALGORITHM_HMAC_SHA384: sha512.New384,
+	ALGORITHM_HMAC_SHA512: sha512.New,
+}
+
+// SigningAlgorithms returns the names of the supported signing algorithms
+func SigningAlgorithms() []string {
+	var names []string
+	for name := range signingAlgorithms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func checkAlgorithm(algorithm string) error {
+	if _, ok := signingAlgorithms[algorithm]; !ok {
+		return fmt.Errorf("unsupported signing algorithm %s", algorithm)
+	}
+	return nil
+}
+
+// hmacSum computes the HMAC of data using the hash of the given algorithm
+func hmacSum(algorithm string, data []byte, key []byte) []byte {
+	newHash, ok := signingAlgorithms[algorithm]
+	if !ok {
+		newHash = sha256.New
+	}
+	mac := hmac.New(newHash, key)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
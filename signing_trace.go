@@ -0,0 +1,70 @@
+package client
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+// Shown instead of key material which must not leave the signer
+const redacted = "<redacted>"
+
+// SigningTrace explains how an Authorization header value was computed.
+// Compare the traces of both sides when a signature does not match.
+// The secret is never included and the intermediate keys are only
+// represented by a truncated SHA-256 fingerprint
+type SigningTrace struct {
+	Algorithm        string     `json:"algorithm"`
+	Credential       string     `json:"credential"`
+	Method           string     `json:"method"`
+	QueryString      string     `json:"queryString"`
+	CanonicalHeaders string     `json:"canonicalHeaders"`
+	BodyLength       int        `json:"bodyLength"`
+	BodyDigest       string     `json:"bodyDigest"` // Hex encoded SHA-256 of the body
+	URL              string     `json:"url"`        // The URL path which is signed
+	Keys             []TraceKey `json:"keys"`
+	Signature        string     `json:"signature"`
+	Authorization    string     `json:"authorization"`
+}
+
+// TraceKey is an intermediate key of the signing chain
+type TraceKey struct {
+	Name        string `json:"name"`
+	Fingerprint string `json:"fingerprint"`
+}
+
+// String renders the trace as human readable text
+func (trace *SigningTrace) String() string {
+	buffer := bytes.NewBufferString("")
+	fmt.Fprintf(buffer, "Algorithm:         %s\n", trace.Algorithm)
+	fmt.Fprintf(buffer, "Credential:        %s\n", trace.Credential)
+	fmt.Fprintf(buffer, "Method:            %s\n", trace.Method)
+	fmt.Fprintf(buffer, "Query string:      %s\n", trace.QueryString)
+	fmt.Fprintf(buffer, "Canonical headers: %s\n", trace.CanonicalHeaders)
+	fmt.Fprintf(buffer, "Body:              %d bytes, sha256 %s\n", trace.BodyLength, trace.BodyDigest)
+	fmt.Fprintf(buffer, "URL signed:        %s\n", trace.URL)
+	for _, key := range trace.Keys {
+		fmt.Fprintf(buffer, "%-19s%s\n", key.Name+":", key.Fingerprint)
+	}
+	fmt.Fprintf(buffer, "Signature:         %s\n", trace.Signature)
+	fmt.Fprintf(buffer, "Authorization:     %s", trace.Authorization)
+	return buffer.String()
+}
+
+// JSON renders the trace as indented JSON
+func (trace *SigningTrace) JSON() ([]byte, error) {
+	return json.MarshalIndent(trace, "", "  ")
+}
+
+func digest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// fingerprint identifies a key without disclosing it
+func fingerprint(key []byte) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:8])
+}
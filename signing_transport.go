@@ -0,0 +1,91 @@
+package client
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// SigningTransport is an http.RoundTripper which signs every outgoing request
+// the DHP way. It allows plain http.Client users and generated API clients to
+// talk to DHP without going through ApiClient:
+//
+//	httpClient := &http.Client{Transport: client.NewSigningTransport(key, secret, nil)}
+//
+// Each round trip, including the ones for redirects and retries, gets a fresh
+// SignedDate unless the request already carries one. Only the headers named
+// by the Signer are signed, TransportSignedHeaders when it names none, as
+// http.Client and the transports below it add headers of their own
+type SigningTransport struct {
+	Signer ApiSigner
+	Base   http.RoundTripper // The transport doing the actual work, nil means DefaultTransport
+}
+
+// TransportSignedHeaders are the headers a SigningTransport signs by default
+var TransportSignedHeaders = []string{"SignedDate", "Api-Version", "Content-Type"}
+
+// NewSigningTransport creates a SigningTransport using the given signing
+// credentials on top of base
+func NewSigningTransport(sharedKey, secretKey string, base http.RoundTripper) *SigningTransport {
+	transport := &SigningTransport{Base: base}
+	transport.Signer.Init(sharedKey, secretKey, false)
+	return transport
+}
+
+// RoundTrip implements http.RoundTripper. The request passed in is not
+// modified, a signed copy of it is sent instead
+func (transport *SigningTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := requestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	signed := req.Clone(req.Context())
+	if req.Body != nil && req.Body != http.NoBody {
+		signed.Body = ioutil.NopCloser(bytes.NewReader(body))
+		signed.GetBody = func() (io.ReadCloser, error) {
+			return ioutil.NopCloser(bytes.NewReader(body)), nil
+		}
+		signed.ContentLength = int64(len(body))
+	}
+	if signed.Header == nil {
+		signed.Header = http.Header{}
+	}
+
+	signed.Header.Del("Authorization")
+	if signed.Header.Get("SignedDate") == "" {
+		signed.Header.Set("SignedDate", time.Now().UTC().Format(TIME_FORMAT))
+	}
+	signer := transport.Signer
+	if len(signer.signedHeaders) == 0 {
+		signer.SignHeaders(TransportSignedHeaders...)
+	}
+	authHeaderValue := signer.BuildAuthorizationHeaderValue(signed.Method, signed.URL.RawQuery, &signed.Header, signed.URL.Path, body)
+	signed.Header.Set("Authorization", authHeaderValue)
+
+	base := transport.Base
+	if base == nil {
+		base = DefaultTransport
+	}
+	return base.RoundTrip(signed)
+}
+
+// requestBody returns the full body of an outgoing request. GetBody is
+// preferred so the original body is left untouched for redirects, otherwise
+// the body is consumed and closed as RoundTrip is required to do
+func requestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return []byte{}, nil
+	}
+	reader := req.Body
+	if req.GetBody != nil {
+		fresh, err := req.GetBody()
+		if err == nil {
+			req.Body.Close()
+			reader = fresh
+		}
+	}
+	defer reader.Close()
+	return ioutil.ReadAll(reader)
+}
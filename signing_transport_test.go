@@ -0,0 +1,100 @@
+package client
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newVerifyingServer answers with the credential and body of requests
+// passing SignatureFilter and records their Authorization header
+func newVerifyingServer(authorization *string) *httptest.Server {
+	store := NewKeyStore(map[string]string{"key": "secret"})
+	filter := SignatureFilter(&Verifier{KeyLookup: store.Lookup})
+	return httptest.NewServer(filter(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*authorization = r.Header.Get("Authorization")
+		body, _ := ioutil.ReadAll(r.Body)
+		credential, _ := CredentialFromContext(r.Context())
+		w.Write([]byte(credential + string(body)))
+	})))
+}
+
+func TestSigningTransport(t *testing.T) {
+	var authorization string
+	srv := newVerifyingServer(&authorization)
+	defer srv.Close()
+	httpClient := &http.Client{Transport: NewSigningTransport("key", "secret", nil)}
+	req, _ := http.NewRequest("POST", srv.URL+"/authentication/login?applicationName=app", strings.NewReader(`{"a":1}`))
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("Api-Version", "2")
+	req.Header.Set("X-Request-Id", "id")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || string(body) != `key{"a":1}` {
+		t.Fatalf("expected the request to verify, got %d %s", resp.StatusCode, body)
+	}
+	if !strings.Contains(authorization, "SignedHeaders:Api-Version,Content-Type,SignedDate;") {
+		t.Errorf("expected only the allow-listed headers to be signed, got %s", authorization)
+	}
+	if req.Header.Get("Authorization") != "" {
+		t.Error("expected the request passed in to be left alone")
+	}
+}
+
+func TestSigningTransportSignHeaders(t *testing.T) {
+	var authorization string
+	srv := newVerifyingServer(&authorization)
+	defer srv.Close()
+	transport := NewSigningTransport("key", "secret", nil)
+	transport.Signer.SignHeaders("X-Request-Id")
+	req, _ := http.NewRequest("GET", srv.URL+"/x", nil)
+	req.Header.Set("Api-Version", "2")
+	req.Header.Set("X-Request-Id", "id")
+	resp, err := (&http.Client{Transport: transport}).Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected the request to verify, got %d", resp.StatusCode)
+	}
+	if !strings.Contains(authorization, "SignedHeaders:SignedDate,X-Request-Id;") {
+		t.Errorf("expected the headers chosen on the Signer to be signed, got %s", authorization)
+	}
+}
+
+func TestSigningTransportRejectedWithoutSignature(t *testing.T) {
+	var authorization string
+	srv := newVerifyingServer(&authorization)
+	defer srv.Close()
+	resp, err := http.Get(srv.URL + "/x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		t.Error("expected an unsigned request to be rejected")
+	}
+}
+
+func TestSigningTransportNilHeader(t *testing.T) {
+	var authorization string
+	srv := newVerifyingServer(&authorization)
+	defer srv.Close()
+	req, _ := http.NewRequest("GET", srv.URL+"/x", nil)
+	req.Header = nil
+	resp, err := NewSigningTransport("key", "secret", nil).RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || req.Header != nil {
+		t.Errorf("expected a verified request without touching the original, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,249 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// ConformanceSuiteVersion is bumped whenever vectors are changed or removed.
+// Adding vectors does not change the version
+const ConformanceSuiteVersion = 1
+
+// ConformanceSuite is the set of signing vectors other implementations
+// of the DHP signing algorithm are checked against
+type ConformanceSuite struct {
+	Version   int             `json:"version"`
+	Algorithm string          `json:"algorithm"`
+	Vectors   []SigningVector `json:"vectors"`
+}
+
+// SigningVector is a known good input and output of the DHP signing algorithm.
+// The vectors pin down canonicalization details so changes to the signer
+// cannot silently alter the signatures DHP receives. Next to the final
+// Authorization value the intermediate results are listed
+type SigningVector struct {
+	Name             string      `json:"name"`
+	Algorithm        string      `json:"algorithm,omitempty"` // Defaults to the algorithm of the suite, or ALGORITHM_NAME on its own
+	SharedKey        string      `json:"sharedKey"`
+	SecretKey        string      `json:"secretKey"`
+	Method           string      `json:"method"`
+	Path             string      `json:"path"`
+	Query            string      `json:"query"`
+	Headers          http.Header `json:"headers"`
+	SignedHeaders    []string    `json:"signedHeaders,omitempty"`
+	Body             string      `json:"body"`
+	CanonicalHeaders string      `json:"canonicalHeaders"`
+	Signature        string      `json:"signature"`
+	Authorization    string      `json:"authorization"`
+}
+
+// NewConformanceSuite returns the current conformance suite
+func NewConformanceSuite() ConformanceSuite {
+	return ConformanceSuite{
+		Version:   ConformanceSuiteVersion,
+		Algorithm: ALGORITHM_NAME,
+		Vectors:   SigningVectors(),
+	}
+}
+
+// Check verifies every vector of the suite against this implementation
+func (suite ConformanceSuite) Check() []error {
+	var errs []error
+	if suite.Version != ConformanceSuiteVersion {
+		errs = append(errs, fmt.Errorf("conformance suite version %d, want %d", suite.Version, ConformanceSuiteVersion))
+	}
+	if suite.Algorithm != "" {
+		if err := checkAlgorithm(suite.Algorithm); err != nil {
+			return append(errs, err)
+		}
+	}
+	for _, vector := range suite.Vectors {
+		if vector.Algorithm == "" {
+			vector.Algorithm = suite.Algorithm
+		}
+		if err := vector.Check(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// SigningVectors returns the golden signing vectors
+func SigningVectors() []SigningVector {
+	return []SigningVector{
+		{
+			Name:      "get-token-status",
+			SharedKey: "shared-key",
+			SecretKey: "secret-key",
+			Method:    "GET",
+			Path:      "/authentication/users/4f5e7c1e-8d3b-4c8e-9d2a-1b7f0e6a5c3d/tokenStatus",
+			Query:     "applicationName=dhpclient",
+			Headers: http.Header{
+				"SignedDate":  {"2019-02-01T12:00:00.000+0000"},
+				"AccessToken": {"access-token"},
+			},
+			CanonicalHeaders: "Accesstoken:access-token;SignedDate:2019-02-01T12:00:00.000+0000;",
+			Signature:        "/poIvyh30JRi2u0nRkPY6k/2j4GjHHJKJH/5uJEdCA8=",
+			Authorization:    "HmacSHA256;Credential:shared-key;SignedHeaders:Accesstoken,SignedDate;Signature:/poIvyh30JRi2u0nRkPY6k/2j4GjHHJKJH/5uJEdCA8=",
+		},
+		{
+			Name:      "post-login-with-body",
+			SharedKey: "shared-key",
+			SecretKey: "secret-key",
+			Method:    "POST",
+			Path:      "/authentication/login",
+			Query:     "applicationName=dhpclient",
+			Headers: http.Header{
+				"SignedDate":  {"2019-02-01T12:00:00.000+0000"},
+				"Api-Version": {"2"},
+			},
+			Body:             `{"loginId":"user@example.com","password":"Secret123!"}`,
+			CanonicalHeaders: "Api-Version:2;SignedDate:2019-02-01T12:00:00.000+0000;",
+			Signature:        "xI2gsBxR7zcH2vXPYapnYIroWSShShsTvh0T17qrwiM=",
+			Authorization:    "HmacSHA256;Credential:shared-key;SignedHeaders:Api-Version,SignedDate;Signature:xI2gsBxR7zcH2vXPYapnYIroWSShShsTvh0T17qrwiM=",
+		},
+		{
+			Name:      "no-query-only-signed-date",
+			SharedKey: "shared-key",
+			SecretKey: "secret-key",
+			Method:    "PUT",
+			Path:      "/subscription/applications/dhpclient/users/4f5e7c1e/close",
+			Headers: http.Header{
+				"SignedDate": {"2019-02-01T12:00:00.000+0000"},
+			},
+			Body:             `{"deleteDataFlag":"Yes"}`,
+			CanonicalHeaders: "SignedDate:2019-02-01T12:00:00.000+0000;",
+			Signature:        "41lvUUWY4QbcZMrlKz6TusXvC0kAT8tJf/aP/QPBP44=",
+			Authorization:    "HmacSHA256;Credential:shared-key;SignedHeaders:SignedDate;Signature:41lvUUWY4QbcZMrlKz6TusXvC0kAT8tJf/aP/QPBP44=",
+		},
+		{
+			Name:      "multi-valued-header",
+			SharedKey: "shared-key",
+			SecretKey: "secret-key",
+			Method:    "GET",
+			Path:      "/usermanagement/users/4f5e7c1e/profile",
+			Query:     "applicationName=dhpclient",
+			Headers: http.Header{
+				"SignedDate":  {"2019-02-01T12:00:00.000+0000"},
+				"Api-Version": {"1"},
+				"X-Tenant":    {"b", "a"},
+			},
+			CanonicalHeaders: "Api-Version:1;SignedDate:2019-02-01T12:00:00.000+0000;X-Tenant:b,a;",
+			Signature:        "nbi3BBNMSEZ1GxsR8nOtwLq+8It8X2si8bQXPkL4Vdg=",
+			Authorization:    "HmacSHA256;Credential:shared-key;SignedHeaders:Api-Version,SignedDate,X-Tenant;Signature:nbi3BBNMSEZ1GxsR8nOtwLq+8It8X2si8bQXPkL4Vdg=",
+		},
+		{
+			Name:      "selected-signed-headers",
+			SharedKey: "shared-key",
+			SecretKey: "secret-key",
+			Method:    "GET",
+			Path:      "/usermanagement/users/4f5e7c1e/profile",
+			Query:     "applicationName=dhpclient",
+			Headers: http.Header{
+				"SignedDate":      {"2019-02-01T12:00:00.000+0000"},
+				"Api-Version":     {"1"},
+				"X-Forwarded-For": {"10.0.0.1"},
+			},
+			SignedHeaders:    []string{"Api-Version"},
+			CanonicalHeaders: "Api-Version:1;SignedDate:2019-02-01T12:00:00.000+0000;",
+			Signature:        "4XLi0+wWKg4bEf+ghS/r0C805Ag3alygpeRsX/1JKoI=",
+			Authorization:    "HmacSHA256;Credential:shared-key;SignedHeaders:Api-Version,SignedDate;Signature:4XLi0+wWKg4bEf+ghS/r0C805Ag3alygpeRsX/1JKoI=",
+		},
+		{
+			Name:      "header-value-with-semicolon",
+			SharedKey: "shared-key",
+			SecretKey: "secret-key",
+			Method:    "POST",
+			Path:      "/authentication/login",
+			Query:     "applicationName=dhpclient",
+			Headers: http.Header{
+				"SignedDate":   {"2019-02-01T12:00:00.000+0000"},
+				"Api-Version":  {"2"},
+				"Content-Type": {"application/json; charset=utf-8"},
+			},
+			Body:             `{"loginId":"user@example.com","password":"Secret123!"}`,
+			CanonicalHeaders: "Api-Version:2;Content-Type:application/json; charset=utf-8;SignedDate:2019-02-01T12:00:00.000+0000;",
+			Signature:        "3IaI8qINfPH6uTiXfKLHjEWCIhtjWPYKl/kUGiL67zM=",
+			Authorization:    "HmacSHA256;Credential:shared-key;SignedHeaders:Api-Version,Content-Type,SignedDate;Signature:3IaI8qINfPH6uTiXfKLHjEWCIhtjWPYKl/kUGiL67zM=",
+		},
+		{
+			Name:             "no-headers",
+			SharedKey:        "shared-key",
+			SecretKey:        "secret-key",
+			Method:           "GET",
+			Path:             "/",
+			Headers:          http.Header{},
+			CanonicalHeaders: ";",
+			Signature:        "fED2nD+ZSActJ1xByLpsM7Il7fLct7kVMdjdDfF2mi8=",
+			Authorization:    "HmacSHA256;Credential:shared-key;SignedHeaders:;Signature:fED2nD+ZSActJ1xByLpsM7Il7fLct7kVMdjdDfF2mi8=",
+		},
+		{
+			Name:      "post-login-hmac-sha384",
+			Algorithm: ALGORITHM_HMAC_SHA384,
+			SharedKey: "shared-key",
+			SecretKey: "secret-key",
+			Method:    "POST",
+			Path:      "/authentication/login",
+			Query:     "applicationName=dhpclient",
+			Headers: http.Header{
+				"SignedDate":  {"2019-02-01T12:00:00.000+0000"},
+				"Api-Version": {"2"},
+			},
+			Body:             `{"loginId":"user@example.com","password":"Secret123!"}`,
+			CanonicalHeaders: "Api-Version:2;SignedDate:2019-02-01T12:00:00.000+0000;",
+			Signature:        "HRQWcdBmQrOJMsyEu96H9UsqtMkXRhpU/4PuIMsjYSEx1KAmtkwpZibNtsEi+yBT",
+			Authorization:    "HmacSHA384;Credential:shared-key;SignedHeaders:Api-Version,SignedDate;Signature:HRQWcdBmQrOJMsyEu96H9UsqtMkXRhpU/4PuIMsjYSEx1KAmtkwpZibNtsEi+yBT",
+		},
+		{
+			Name:      "post-login-hmac-sha512",
+			Algorithm: ALGORITHM_HMAC_SHA512,
+			SharedKey: "shared-key",
+			SecretKey: "secret-key",
+			Method:    "POST",
+			Path:      "/authentication/login",
+			Query:     "applicationName=dhpclient",
+			Headers: http.Header{
+				"SignedDate":  {"2019-02-01T12:00:00.000+0000"},
+				"Api-Version": {"2"},
+			},
+			Body:             `{"loginId":"user@example.com","password":"Secret123!"}`,
+			CanonicalHeaders: "Api-Version:2;SignedDate:2019-02-01T12:00:00.000+0000;",
+			Signature:        "HeKeg5Zk8voHe6wlEghQ35jz17puGt/JLgrBjoUFbvDRIpWjPfa6otjwL5HpvdqPLj7tFu1hEaq5zScq4EBrPA==",
+			Authorization:    "HmacSHA512;Credential:shared-key;SignedHeaders:Api-Version,SignedDate;Signature:HeKeg5Zk8voHe6wlEghQ35jz17puGt/JLgrBjoUFbvDRIpWjPfa6otjwL5HpvdqPLj7tFu1hEaq5zScq4EBrPA==",
+		},
+	}
+}
+
+// algorithm returns the algorithm of the vector. ConformanceSuite.Check fills
+// in the suite algorithm, a vector checked on its own uses ALGORITHM_NAME
+func (vector SigningVector) algorithm() string {
+	if vector.Algorithm == "" {
+		return ALGORITHM_NAME
+	}
+	return vector.Algorithm
+}
+
+// Sign computes the Authorization header value for the vector
+func (vector SigningVector) Sign() string {
+	algorithm := vector.algorithm()
+	canonicalHeaders := CanonicalHeaders(vector.Headers, vector.SignedHeaders...)
+	signingKey := SigningKey(algorithm, vector.SecretKey, vector.Method, vector.Query, []byte(vector.Body), canonicalHeaders)
+	signedHeaders := SignedHeaderNames(vector.Headers, vector.SignedHeaders...)
+	return AuthorizationHeaderValue(algorithm, vector.SharedKey, signedHeaders, Signature(algorithm, signingKey, vector.Path))
+}
+
+// Check reports an error when this implementation does not reproduce the vector
+func (vector SigningVector) Check() error {
+	canonicalHeaders := CanonicalHeaders(vector.Headers, vector.SignedHeaders...)
+	if vector.CanonicalHeaders != "" && canonicalHeaders != vector.CanonicalHeaders {
+		return fmt.Errorf("signing vector %s: canonical headers %q, want %q", vector.Name, canonicalHeaders, vector.CanonicalHeaders)
+	}
+	signingKey := SigningKey(vector.algorithm(), vector.SecretKey, vector.Method, vector.Query, []byte(vector.Body), canonicalHeaders)
+	if signature := Signature(vector.algorithm(), signingKey, vector.Path); vector.Signature != "" && signature != vector.Signature {
+		return fmt.Errorf("signing vector %s: signature %s, want %s", vector.Name, signature, vector.Signature)
+	}
+	if got := vector.Sign(); got != vector.Authorization {
+		return fmt.Errorf("signing vector %s: got %s, want %s", vector.Name, got, vector.Authorization)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package client
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"reflect"
+	"testing"
+)
+
+const conformanceSuitePath = "docs/signing_conformance_v1.json"
+
+func TestSigningVectors(t *testing.T) {
+	for _, vector := range SigningVectors() {
+		if err := vector.Check(); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestConformanceSuiteDocument(t *testing.T) {
+	data, err := ioutil.ReadFile(conformanceSuitePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var suite ConformanceSuite
+	if err := json.Unmarshal(data, &suite); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(suite, NewConformanceSuite()) {
+		t.Errorf("%s does not match NewConformanceSuite, regenerate it", conformanceSuitePath)
+	}
+	for _, err := range suite.Check() {
+		t.Error(err)
+	}
+}
+
+func TestConformanceSuiteDetectsChanges(t *testing.T) {
+	suite := NewConformanceSuite()
+	suite.Vectors[0].Headers = suite.Vectors[0].Headers.Clone()
+	suite.Vectors[0].Headers.Set("SignedDate", "2019-02-01T12:00:01.000+0000")
+	if errs := suite.Check(); len(errs) != 1 {
+		t.Errorf("expected the altered vector to fail, got %v", errs)
+	}
+}
+
+func TestConformanceSuiteAlgorithm(t *testing.T) {
+	vector := SigningVectors()[0]
+	vector.Algorithm = ALGORITHM_HMAC_SHA512
+	vector.Signature = ""
+	vector.Authorization = vector.Sign()
+	vector.Algorithm = ""
+	if err := vector.Check(); err == nil {
+		t.Error("expected a vector on its own to be checked with ALGORITHM_NAME")
+	}
+	suite := ConformanceSuite{Version: ConformanceSuiteVersion, Algorithm: ALGORITHM_HMAC_SHA512, Vectors: []SigningVector{vector}}
+	if errs := suite.Check(); len(errs) != 0 {
+		t.Errorf("expected the vector to be checked with the suite algorithm, got %v", errs)
+	}
+	suite.Algorithm = "HmacMD5"
+	if errs := suite.Check(); len(errs) != 1 {
+		t.Errorf("expected an unsupported suite algorithm to fail, got %v", errs)
+	}
+}
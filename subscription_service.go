@@ -0,0 +1,144 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+)
+
+const (
+	RESPONSE_CODE_CONSENT_REQUIRED         = 1138
+	RESPONSE_CODE_DATA_CLEANUP_IN_PROGRESS = 1139
+	RESPONSE_CODE_CLOSE_WITHOUT_DATA_CLEAN = 1148
+
+	// SUBSCRIPTION_API_VERSION is the Api-Version
+	// SubscriptionService uses unless told otherwise
+	SUBSCRIPTION_API_VERSION = "1"
+)
+
+// SubscriptionService manages the subscriptions of users to the
+// application and its propositions. Besides the usual *DHPError failures
+// its methods return errors matching ErrConsentRequired when the user has
+// not accepted the current terms and conditions, ErrDataCleanupInProgress
+// when a previous close is still deleting data and
+// ErrCloseWithoutDataCleanNotSupported
+type SubscriptionService struct {
+	client          *ApiClient
+	PropositionName string // Defaults to the one of the client configuration
+	ApiVersion      string // Sent as Api-Version header, defaults to SUBSCRIPTION_API_VERSION
+}
+
+// NewSubscriptionService returns a service using the given client, which
+// must be configured with DHP_SUBSCRIPTION_SERVICE_URL and signing keys
+func NewSubscriptionService(client *ApiClient) *SubscriptionService {
+	return &SubscriptionService{
+		client:          client,
+		PropositionName: client.config.PropositionName,
+		ApiVersion:      SUBSCRIPTION_API_VERSION,
+	}
+}
+
+// TermsAndConditions is a terms and conditions document
+type TermsAndConditions struct {
+	DocumentId      string `json:"documentId,omitempty"`
+	DocumentVersion string `json:"documentVersion,omitempty"`
+	ConsentCode     string `json:"consentCode,omitempty"`
+	CountryCode     string `json:"countryCode,omitempty"`
+	LanguageCode    string `json:"languageCode,omitempty"`
+	Url             string `json:"url,omitempty"`
+	Content         string `json:"content,omitempty"`
+}
+
+// AcceptTermsRequest records the acceptance of a terms and conditions document
+type AcceptTermsRequest struct {
+	DocumentId                 string `json:"documentId,omitempty"`
+	DocumentVersion            string `json:"documentVersion"`
+	ConsentCode                string `json:"consentCode"`
+	CountryCode                string `json:"countryCode"`
+	ClassCode                  string `json:"classCode,omitempty"`
+	DeviceIdentificationNumber string `json:"deviceIdentificationNumber,omitempty"`
+}
+
+// Subscription is a subscription of a user to a proposition
+type Subscription struct {
+	PropositionName string `json:"propositionName"`
+	Status          string `json:"status,omitempty"`
+	ConsentCode     string `json:"consentCode,omitempty"`
+	DocumentVersion string `json:"documentVersion,omitempty"`
+}
+
+type closeRequest struct {
+	DeleteDataFlag string `json:"deleteDataFlag"`
+}
+
+// TermsAndConditions fetches the documents for a consent code. The access
+// token is optional, without it the request is only signed
+func (s *SubscriptionService) TermsAndConditions(ctx context.Context, userUUID, accessToken, consentCode string) ([]TermsAndConditions, error) {
+	queryParams := "consentCode=" + url.QueryEscape(consentCode) + "&propositionName=" + url.QueryEscape(s.PropositionName)
+	response, err := s.client.Do(ctx, "GET", s.userEndpoint(userUUID, "/termsAndConditions"), queryParams, s.header(accessToken), nil)
+	if err != nil {
+		return nil, err
+	}
+	var documents []TermsAndConditions
+	if _, err := response.decodeExchange(&documents, "exchange.termsAndConditions", "exchange.documents", "exchange"); err == nil {
+		return documents, nil
+	}
+	// A single document
+	var document TermsAndConditions
+	if _, err := response.decodeExchange(&document, "exchange.termsAndConditions", "exchange"); err != nil {
+		return nil, err
+	}
+	return []TermsAndConditions{document}, nil
+}
+
+// AcceptTermsAndConditions records that the user accepted a document version
+func (s *SubscriptionService) AcceptTermsAndConditions(ctx context.Context, userUUID, accessToken string, request AcceptTermsRequest) error {
+	body, _ := json.Marshal(&request)
+	queryParams := "propositionName=" + url.QueryEscape(s.PropositionName)
+	_, err := s.client.Do(ctx, "POST", s.userEndpoint(userUUID, "/termsAndConditions"), queryParams, s.header(accessToken), body)
+	return err
+}
+
+// Subscriptions lists the subscriptions of a user
+func (s *SubscriptionService) Subscriptions(ctx context.Context, userUUID, accessToken string) ([]Subscription, error) {
+	response, err := s.client.Do(ctx, "GET", s.userEndpoint(userUUID, "/subscriptions"), "", s.header(accessToken), nil)
+	if err != nil {
+		return nil, err
+	}
+	var subscriptions []Subscription
+	if _, err := response.decodeExchange(&subscriptions, "exchange.subscriptions", "exchange"); err != nil {
+		return nil, err
+	}
+	return subscriptions, nil
+}
+
+// Close ends the subscription of a user. With deleteData the data of the
+// user is removed as well, which continues in the background: until it
+// is done calls fail with ErrDataCleanupInProgress. Closing without
+// deleting data fails with ErrCloseWithoutDataCleanNotSupported when
+// the application does not allow it
+func (s *SubscriptionService) Close(ctx context.Context, userUUID string, deleteData bool) error {
+	flag := "No"
+	if deleteData {
+		flag = "Yes"
+	}
+	body, _ := json.Marshal(&closeRequest{DeleteDataFlag: flag})
+	_, err := s.client.Do(ctx, "PUT", s.userEndpoint(userUUID, "/close"), "", s.header(""), body)
+	return err
+}
+
+func (s *SubscriptionService) userEndpoint(userUUID, action string) string {
+	return "/subscription/applications/" + url.PathEscape(s.client.DHPApplicationName()) + "/users/" + url.PathEscape(userUUID) + action
+}
+
+func (s *SubscriptionService) header(accessToken string) *http.Header {
+	header := &http.Header{}
+	if s.ApiVersion != "" {
+		header.Set("Api-Version", s.ApiVersion)
+	}
+	if accessToken != "" {
+		header.Set("accessToken", accessToken)
+	}
+	return header
+}
@@ -0,0 +1,71 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestSubscriptionServiceEscapesPathOnce(t *testing.T) {
+	client, requests := newServiceServer(t, `{"responseCode":"200","exchange":{"termsAndConditions":{"documentVersion":"3","consentCode":"2"}}}`)
+	service := NewSubscriptionService(client)
+	ctx := context.Background()
+	documents, err := service.TermsAndConditions(ctx, "user uuid", "", "2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(documents) != 1 || documents[0].DocumentVersion != "3" {
+		t.Errorf("unexpected documents %+v", documents)
+	}
+	if err := service.Close(ctx, "user uuid", true); err != nil {
+		t.Fatal(err)
+	}
+	for i, path := range []string{
+		"/subscription/applications/my%20app/users/user%20uuid/termsAndConditions",
+		"/subscription/applications/my%20app/users/user%20uuid/close",
+	} {
+		request := requests()[i]
+		if request.VerifyErr != nil {
+			t.Errorf("%s: %v", request.Path, request.VerifyErr)
+		}
+		if request.EscapedPath != path {
+			t.Errorf("expected %s, got %s", path, request.EscapedPath)
+		}
+		if request.ApiVersion != SUBSCRIPTION_API_VERSION {
+			t.Errorf("expected Api-Version %s, got %s", SUBSCRIPTION_API_VERSION, request.ApiVersion)
+		}
+	}
+}
+
+func TestSubscriptionServiceEscapesUserUUID(t *testing.T) {
+	client, requests := newServiceServer(t, `{"responseCode":"200"}`)
+	if err := NewSubscriptionService(client).Close(context.Background(), "a/b?c", true); err != nil {
+		t.Fatal(err)
+	}
+	request := requests()[0]
+	if request.VerifyErr != nil {
+		t.Error(request.VerifyErr)
+	}
+	if request.EscapedPath != "/subscription/applications/my%20app/users/a%2Fb%3Fc/close" {
+		t.Errorf("expected the user UUID to stay one segment, got %s", request.EscapedPath)
+	}
+}
+
+func TestSubscriptionServiceListForms(t *testing.T) {
+	client, _ := newServiceServer(t, `{"responseCode":"200","exchange":[{"documentVersion":"3"},{"documentVersion":"4"}]}`)
+	documents, err := NewSubscriptionService(client).TermsAndConditions(context.Background(), "uuid", "", "2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(documents) != 2 {
+		t.Errorf("expected 2 documents, got %+v", documents)
+	}
+}
+
+func TestSubscriptionServiceCloseErrors(t *testing.T) {
+	client, _ := newServiceServer(t, `{"responseCode":"1148"}`)
+	err := NewSubscriptionService(client).Close(context.Background(), "uuid", false)
+	if !errors.Is(err, ErrCloseWithoutDataCleanNotSupported) {
+		t.Errorf("expected ErrCloseWithoutDataCleanNotSupported, got %v", err)
+	}
+}
@@ -0,0 +1,157 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultTrackerConcurrency is how many transactions
+// WaitAll polls at once unless told otherwise
+const DefaultTrackerConcurrency = 4
+
+// DefaultMaxNotAvailable is how many polls in a row TransactionTracker lets
+// DHP answer 1501 before giving up on a transaction id it does not know
+const DefaultMaxNotAvailable = 10
+
+// ErrTransactionPending is returned by TransactionTracker when the
+// polling policy allows no more polls before a terminal state is reached
+var ErrTransactionPending = errors.New("observation transaction still pending")
+
+// DefaultPollingPolicy returns the policy TransactionTracker uses unless told
+// otherwise: poll until the context is done, starting after a second and
+// backing off to 30 seconds. A transaction id DHP does not know yet (1501),
+// up to MaxNotAvailable times in a row, and transient transaction service
+// failures (1517) keep the polling going. The Retry policy of the client is
+// not used for polls
+func DefaultPollingPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		InitialBackoff:       time.Second,
+		MaxBackoff:           30 * time.Second,
+		Jitter:               0.2,
+		RetryableStatusCodes: []int{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout},
+		RetryableDhpCodes:    []int{RESPONSE_CODE_OBS_TRANSACTION_NOT_AVAILABLE, RESPONSE_CODE_OBS_TRANSACTION_SERVICE_FAILED},
+		RetryNetworkErrors:   true,
+	}
+}
+
+// TrackedTransaction identifies an observation upload to follow
+type TrackedTransaction struct {
+	UserUUID      string
+	AccessToken   string // Optional, without it requests are only signed
+	TransactionId string
+}
+
+// TransactionResult is the outcome of waiting on a transaction
+type TransactionResult struct {
+	TrackedTransaction
+	Transaction *Transaction // The last state seen, nil when none was
+	Err         error
+}
+
+// TransactionTracker waits for observation uploads to be processed by
+// polling their state until it is STATE_COMPLETED or STATE_FAILED
+type TransactionTracker struct {
+	service         *ObservationService
+	Polling         *RetryPolicy // Wait between polls and errors to keep polling on, MaxAttempts 0 polls until the context is done
+	Concurrency     int          // Transactions polled at once by WaitAll, defaults to DefaultTrackerConcurrency
+	MaxNotAvailable int          // Polls in a row answered with 1501 before giving up, defaults to DefaultMaxNotAvailable
+}
+
+// NewTransactionTracker returns a tracker using DefaultPollingPolicy
+func NewTransactionTracker(service *ObservationService) *TransactionTracker {
+	return &TransactionTracker{
+		service:         service,
+		Polling:         DefaultPollingPolicy(),
+		Concurrency:     DefaultTrackerConcurrency,
+		MaxNotAvailable: DefaultMaxNotAvailable,
+	}
+}
+
+// Wait polls a transaction until it reaches a terminal state. A failed
+// transaction is not an error, check the State of the result. When ctx is
+// done first the last state seen is returned together with ctx.Err().
+// 1521 Invalid TransactionTracker service API ends the wait right away
+func (tracker *TransactionTracker) Wait(ctx context.Context, tracked TrackedTransaction) (*Transaction, error) {
+	policy := tracker.Polling
+	if policy == nil {
+		policy = DefaultPollingPolicy()
+	}
+	maxNotAvailable := tracker.MaxNotAvailable
+	if maxNotAvailable < 1 {
+		maxNotAvailable = DefaultMaxNotAvailable
+	}
+	// Polling is the only retry layer, each poll is a single request
+	pollCtx := WithRetryPolicy(ctx, nil)
+	var last *Transaction
+	notAvailable := 0
+	for poll := 1; ; poll++ {
+		transaction, err := tracker.service.Transaction(pollCtx, tracked.UserUUID, tracked.AccessToken, tracked.TransactionId)
+		var response *Response
+		var dhpErr *DHPError
+		if errors.As(err, &dhpErr) {
+			response = dhpErr.Response
+		}
+		if errors.Is(err, ErrTransactionNotAvailable) {
+			notAvailable++
+		} else {
+			notAvailable = 0
+		}
+		switch {
+		case err == nil && transaction.State.Terminal():
+			return transaction, nil
+		case err == nil:
+			last = transaction
+		case ctx.Err() != nil:
+			return last, ctx.Err()
+		case errors.Is(err, ErrObservationInvalidTrackerAPI):
+			return last, err
+		case notAvailable >= maxNotAvailable:
+			return last, err
+		case !policy.retryable(ctx, "GET", response, err):
+			return last, err
+		}
+		if policy.MaxAttempts > 0 && poll >= policy.MaxAttempts {
+			return last, ErrTransactionPending
+		}
+		wait := policy.backoff(poll, response)
+		if tracker.service.client.config.Debug {
+			log.Info("Transaction ", tracked.TransactionId, " pending, polling again after ", wait)
+		}
+		if err := sleep(ctx, wait); err != nil {
+			return last, err
+		}
+	}
+}
+
+// WaitAll waits on many transactions, polling at most Concurrency of them
+// at once. The results are in the order of the transactions passed in
+func (tracker *TransactionTracker) WaitAll(ctx context.Context, transactions []TrackedTransaction) []TransactionResult {
+	concurrency := tracker.Concurrency
+	if concurrency < 1 {
+		concurrency = DefaultTrackerConcurrency
+	}
+	results := make([]TransactionResult, len(transactions))
+	slots := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, tracked := range transactions {
+		slots <- struct{}{}
+		wg.Add(1)
+		go func(i int, tracked TrackedTransaction) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			transaction, err := tracker.Wait(ctx, tracked)
+			results[i] = TransactionResult{
+				TrackedTransaction: tracked,
+				Transaction:        transaction,
+				Err:                err,
+			}
+		}(i, tracked)
+	}
+	wg.Wait()
+	return results
+}
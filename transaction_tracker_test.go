@@ -0,0 +1,147 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTransactionServer answers transaction polls: ids starting with unknown
+// always get 1501, ids starting with invalid get 1521 with 503, others are in progress on the first poll and completed
+// after that. It returns the number of polls per id and the peak number of
+// polls in flight at once
+func newTransactionServer(t *testing.T) (*TransactionTracker, func(id string) int, *int32) {
+	var mu sync.Mutex
+	polls := map[string]int{}
+	var inFlight, peak int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for p := atomic.LoadInt32(&peak); n > p && !atomic.CompareAndSwapInt32(&peak, p, n); p = atomic.LoadInt32(&peak) {
+		}
+		time.Sleep(2 * time.Millisecond)
+		id := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+		mu.Lock()
+		polls[id]++
+		poll := polls[id]
+		mu.Unlock()
+		switch {
+		case strings.HasPrefix(id, "unknown"):
+			w.Write([]byte(`{"responseCode":"1501"}`))
+		case strings.HasPrefix(id, "invalid"):
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"responseCode":"1521"}`))
+		case poll == 1:
+			w.Write([]byte(`{"responseCode":"200","exchange":{"state":"STATE_IN_PROGRESS"}}`))
+		default:
+			w.Write([]byte(`{"responseCode":"200","exchange":{"state":"STATE_COMPLETED"}}`))
+		}
+	}))
+	t.Cleanup(srv.Close)
+	client, _ := NewClient(ApiClientConfig{ApiBaseUrl: srv.URL, DhpApplicationName: "app", SigningKey: "key", SigningSecret: "secret"})
+	tracker := NewTransactionTracker(NewObservationService(client))
+	tracker.Polling.InitialBackoff = time.Millisecond
+	tracker.Polling.MaxBackoff = time.Millisecond
+	return tracker, func(id string) int {
+		mu.Lock()
+		defer mu.Unlock()
+		return polls[id]
+	}, &peak
+}
+
+func TestTransactionTrackerWait(t *testing.T) {
+	tracker, polls, _ := newTransactionServer(t)
+	transaction, err := tracker.Wait(context.Background(), TrackedTransaction{UserUUID: "uuid", TransactionId: "tx"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transaction.State != TRANSACTION_STATE_COMPLETED || polls("tx") != 2 {
+		t.Errorf("expected completion on the second poll, got %s after %d", transaction.State, polls("tx"))
+	}
+}
+
+func TestTransactionTrackerGivesUpOnUnknownId(t *testing.T) {
+	tracker, polls, _ := newTransactionServer(t)
+	tracker.MaxNotAvailable = 3
+	// Retries of the client would multiply the polls
+	tracker.service.client.config.Retry = &RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, RetryableDhpCodes: []int{RESPONSE_CODE_OBS_TRANSACTION_NOT_AVAILABLE}}
+	_, err := tracker.Wait(context.Background(), TrackedTransaction{UserUUID: "uuid", TransactionId: "unknown"})
+	if !errors.Is(err, ErrTransactionNotAvailable) {
+		t.Fatalf("expected ErrTransactionNotAvailable, got %v", err)
+	}
+	if polls("unknown") != 3 {
+		t.Errorf("expected 3 polls, got %d", polls("unknown"))
+	}
+}
+
+func TestTransactionTrackerWaitAll(t *testing.T) {
+	tracker, _, peak := newTransactionServer(t)
+	tracker.Concurrency = 2
+	tracker.MaxNotAvailable = 2
+	var transactions []TrackedTransaction
+	for _, id := range []string{"a", "b", "c", "d", "unknown"} {
+		transactions = append(transactions, TrackedTransaction{UserUUID: "uuid", TransactionId: id})
+	}
+	results := tracker.WaitAll(context.Background(), transactions)
+	for i, result := range results {
+		if result.TransactionId != transactions[i].TransactionId {
+			t.Errorf("expected result %d for %s, got %s", i, transactions[i].TransactionId, result.TransactionId)
+		}
+	}
+	for _, result := range results[:4] {
+		if result.Err != nil || result.Transaction.State != TRANSACTION_STATE_COMPLETED {
+			t.Errorf("%s: unexpected result %+v", result.TransactionId, result)
+		}
+	}
+	if !errors.Is(results[4].Err, ErrTransactionNotAvailable) {
+		t.Errorf("expected ErrTransactionNotAvailable, got %v", results[4].Err)
+	}
+	if atomic.LoadInt32(peak) > 2 {
+		t.Errorf("expected at most 2 polls at once, got %d", atomic.LoadInt32(peak))
+	}
+}
+
+func TestTransactionTrackerStopsOnInvalidTrackerAPI(t *testing.T) {
+	tracker, polls, _ := newTransactionServer(t)
+	tracker.service.client.config.Retry = DefaultRetryPolicy()
+	_, err := tracker.Wait(context.Background(), TrackedTransaction{UserUUID: "uuid", TransactionId: "invalid"})
+	if !errors.Is(err, ErrObservationInvalidTrackerAPI) {
+		t.Fatalf("expected ErrObservationInvalidTrackerAPI, got %v", err)
+	}
+	if polls("invalid") != 1 {
+		t.Errorf("expected a single poll, got %d", polls("invalid"))
+	}
+}
+
+func TestTransactionTrackerContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var polls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&polls, 1) == 1 {
+			w.Write([]byte(`{"responseCode":"200","exchange":{"state":"STATE_IN_PROGRESS"}}`))
+			return
+		}
+		// Cancel while the second poll is in flight and never answer it
+		cancel()
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+	client, _ := NewClient(ApiClientConfig{ApiBaseUrl: srv.URL, DhpApplicationName: "app", SigningKey: "key", SigningSecret: "secret"})
+	tracker := NewTransactionTracker(NewObservationService(client))
+	tracker.Polling.InitialBackoff = time.Millisecond
+	tracker.Polling.MaxBackoff = time.Millisecond
+	transaction, err := tracker.Wait(ctx, TrackedTransaction{UserUUID: "uuid", TransactionId: "tx"})
+	if err != context.Canceled {
+		t.Fatalf("expected the cancellation to end the wait, got %v", err)
+	}
+	if transaction == nil || transaction.State != TRANSACTION_STATE_IN_PROGRESS {
+		t.Errorf("expected the last state seen, got %+v", transaction)
+	}
+}
@@ -0,0 +1,237 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/Jeffail/gabs/v2"
+)
+
+// UserProfile is the profile DHP keeps for a user. Fields DHP returns but
+// which are not modelled here are kept in Other, so a profile fetched with
+// Get can be passed to Update without losing anything
+type UserProfile struct {
+	GivenName             string                 `json:"givenName,omitempty"`
+	MiddleName            string                 `json:"middleName,omitempty"`
+	FamilyName            string                 `json:"familyName,omitempty"`
+	DisplayName           string                 `json:"displayName,omitempty"`
+	Gender                string                 `json:"gender,omitempty"`
+	Birthday              string                 `json:"birthday,omitempty"` // YYYY-MM-DD
+	Country               string                 `json:"country,omitempty"`
+	CurrentLocation       string                 `json:"currentLocation,omitempty"`
+	Locale                string                 `json:"locale,omitempty"`
+	PreferredLanguage     string                 `json:"preferredLanguage,omitempty"`
+	TimeZone              string                 `json:"timeZone,omitempty"`
+	UnitSystem            string                 `json:"unitSystem,omitempty"`
+	Height                Measure                `json:"height,omitempty"`
+	Weight                Measure                `json:"weight,omitempty"`
+	ReceiveMarketingEmail YesNo                  `json:"receiveMarketingEmail,omitempty"`
+	Photo                 *UserPhoto             `json:"photo,omitempty"`
+	Preferences           map[string]interface{} `json:"preferences,omitempty"`
+
+	Other map[string]json.RawMessage `json:"-"`
+}
+
+// UserPhoto is a profile picture
+type UserPhoto struct {
+	Type  string `json:"type"`  // File extension, e.g. png
+	Value string `json:"value"` // Base64 encoded content of at most 2MB
+}
+
+// Measure is a height or weight. DHP sends it as a number or as a string
+// holding one, depending on how the profile was stored
+type Measure float64
+
+// UnmarshalJSON implements json.Unmarshaler
+func (measure *Measure) UnmarshalJSON(data []byte) error {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	switch v := value.(type) {
+	case nil:
+		*measure = 0
+	case float64:
+		*measure = Measure(v)
+	case string:
+		if strings.TrimSpace(v) == "" {
+			*measure = 0
+			return nil
+		}
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return fmt.Errorf("dhp: invalid measure %q", v)
+		}
+		*measure = Measure(f)
+	default:
+		return fmt.Errorf("dhp: invalid measure %s", data)
+	}
+	return nil
+}
+
+// YesNo is a Yes or No flag. DHP sends it as one of these strings
+// or as a JSON boolean, depending on how the profile was stored
+type YesNo string
+
+// UnmarshalJSON implements json.Unmarshaler
+func (flag *YesNo) UnmarshalJSON(data []byte) error {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	switch v := value.(type) {
+	case nil:
+		*flag = ""
+	case bool:
+		*flag = "No"
+		if v {
+			*flag = "Yes"
+		}
+	case string:
+		*flag = YesNo(v)
+	default:
+		return fmt.Errorf("dhp: invalid flag %s", data)
+	}
+	return nil
+}
+
+// Bool reports whether the flag is set, ignoring case
+func (flag YesNo) Bool() bool {
+	return strings.EqualFold(string(flag), "Yes") || strings.EqualFold(string(flag), "true")
+}
+
+// userProfile has the fields of UserProfile without its JSON methods
+type userProfile UserProfile
+
+// UnmarshalJSON implements json.Unmarshaler
+func (profile *UserProfile) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, (*userProfile)(profile)); err != nil {
+		return err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	for _, name := range userProfileFields {
+		delete(fields, name)
+	}
+	profile.Other = nil
+	if len(fields) > 0 {
+		profile.Other = fields
+	}
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler
+func (profile UserProfile) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(userProfile(profile))
+	if err != nil || len(profile.Other) == 0 {
+		return data, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for name, value := range profile.Other {
+		if _, ok := fields[name]; !ok {
+			fields[name] = value
+		}
+	}
+	return json.Marshal(fields)
+}
+
+// userProfileFields are the JSON names of the fields of UserProfile
+var userProfileFields = []string{
+	"givenName", "middleName", "familyName", "displayName", "gender",
+	"birthday", "country", "currentLocation", "locale", "preferredLanguage",
+	"timeZone", "unitSystem", "height", "weight", "receiveMarketingEmail",
+	"photo", "preferences",
+}
+
+// UserManagementService reads and updates user profiles. With an access
+// token requests are made on behalf of the user, without one they are
+// signed with the keys of the client, e.g. the customer care ones
+type UserManagementService struct {
+	client     *ApiClient
+	ApiVersion string // Optional Api-Version header
+}
+
+// NewUserManagementService returns a service using the given client
+func NewUserManagementService(client *ApiClient) *UserManagementService {
+	return &UserManagementService{client: client}
+}
+
+// Get fetches the profile of a user
+func (s *UserManagementService) Get(ctx context.Context, userUUID, accessToken string) (*UserProfile, error) {
+	response, err := s.do(ctx, "GET", userUUID, accessToken, nil)
+	if err != nil {
+		return nil, err
+	}
+	jsonParsed, err := gabs.ParseJSON([]byte(response.Body))
+	if err != nil {
+		return nil, err
+	}
+	data := jsonParsed.Path("exchange.user.profile")
+	if data.Data() == nil {
+		return nil, errors.New("dhp: no profile in response")
+	}
+	profile := &UserProfile{}
+	if err := json.Unmarshal(data.Bytes(), profile); err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+// Update replaces the profile of a user
+func (s *UserManagementService) Update(ctx context.Context, userUUID, accessToken string, profile *UserProfile) error {
+	body, err := json.Marshal(profile)
+	if err != nil {
+		return err
+	}
+	_, err = s.do(ctx, "PUT", userUUID, accessToken, body)
+	return err
+}
+
+// PatchPreferences fetches the profile of a user, applies the changes to
+// its preferences and stores it again. A nil value removes the preference.
+// The updated profile is returned
+func (s *UserManagementService) PatchPreferences(ctx context.Context, userUUID, accessToken string, changes map[string]interface{}) (*UserProfile, error) {
+	profile, err := s.Get(ctx, userUUID, accessToken)
+	if err != nil {
+		return nil, err
+	}
+	if profile.Preferences == nil {
+		profile.Preferences = map[string]interface{}{}
+	}
+	for key, value := range changes {
+		if value == nil {
+			delete(profile.Preferences, key)
+		} else {
+			profile.Preferences[key] = value
+		}
+	}
+	if err := s.Update(ctx, userUUID, accessToken, profile); err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+func (s *UserManagementService) do(ctx context.Context, method, userUUID, accessToken string, body []byte) (*Response, error) {
+	header := &http.Header{}
+	if s.ApiVersion != "" {
+		header.Set("Api-Version", s.ApiVersion)
+	}
+	apiEndpoint := "/usermanagement/users/" + url.PathEscape(userUUID) + "/profile"
+	queryParams := "applicationName=" + url.QueryEscape(s.client.DHPApplicationName())
+	if accessToken == "" {
+		return s.client.Do(ctx, method, apiEndpoint, queryParams, header, body)
+	}
+	header.Set("AccessToken", accessToken)
+	return s.client.DoRest(ctx, method, apiEndpoint, queryParams, header, body)
+}
@@ -0,0 +1,108 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+// A profile as returned by DHP for a user registered through the mobile app,
+// which stores measures as strings and the marketing flag as a boolean
+const mobileUserProfile = `{"responseCode":"200","exchange":{"user":{"profile":{
+	"givenName":"Jan","familyName":"Jansen","gender":"Male","birthday":"1980-05-01",
+	"height":"180","weight":"75.5","receiveMarketingEmail":true,"locale":"nl-NL",
+	"preferences":{"unit":"metric"},"primaryAddress":{"city":"Eindhoven"}}}}}`
+
+// A profile as returned for a user registered through the web portal
+const webUserProfile = `{"responseCode":"200","exchange":{"user":{"profile":{
+	"givenName":"Jan","height":1.8,"weight":75,"receiveMarketingEmail":"No","photo":null}}}}`
+
+func TestUserManagementServiceGet(t *testing.T) {
+	client, requests := newServiceServer(t, mobileUserProfile)
+	service := NewUserManagementService(client)
+	profile, err := service.Get(context.Background(), "user uuid", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile.Height != 180 || profile.Weight != 75.5 {
+		t.Errorf("expected 180 and 75.5, got %v and %v", profile.Height, profile.Weight)
+	}
+	if profile.ReceiveMarketingEmail != "Yes" || !profile.ReceiveMarketingEmail.Bool() {
+		t.Errorf("expected Yes, got %q", profile.ReceiveMarketingEmail)
+	}
+	if _, ok := profile.Other["primaryAddress"]; !ok {
+		t.Errorf("expected primaryAddress to be kept, got %v", profile.Other)
+	}
+	request := requests()[0]
+	if request.VerifyErr != nil {
+		t.Error(request.VerifyErr)
+	}
+	if request.EscapedPath != "/usermanagement/users/user%20uuid/profile" {
+		t.Errorf("expected the user UUID to be escaped once, got %s", request.EscapedPath)
+	}
+
+	client, _ = newServiceServer(t, webUserProfile)
+	profile, err = NewUserManagementService(client).Get(context.Background(), "uuid", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile.Height != 1.8 || profile.Weight != 75 || profile.ReceiveMarketingEmail.Bool() {
+		t.Errorf("unexpected profile %+v", profile)
+	}
+}
+
+func TestUserManagementServiceEscapesUserUUID(t *testing.T) {
+	client, requests := newServiceServer(t, mobileUserProfile)
+	service := NewUserManagementService(client)
+	for _, accessToken := range []string{"", "token"} {
+		if _, err := service.Get(context.Background(), "a/b?c", accessToken); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, request := range requests() {
+		if request.EscapedPath != "/usermanagement/users/a%2Fb%3Fc/profile" {
+			t.Errorf("expected the user UUID to stay one segment, got %s", request.EscapedPath)
+		}
+	}
+	if request := requests()[0]; request.VerifyErr != nil {
+		t.Error(request.VerifyErr)
+	}
+}
+
+func TestUserProfileLenientFields(t *testing.T) {
+	for data, expected := range map[string]UserProfile{
+		`{"height":"","weight":null,"receiveMarketingEmail":false}`: {ReceiveMarketingEmail: "No"},
+		`{"height":" 1.75 ","receiveMarketingEmail":"Yes"}`:         {Height: 1.75, ReceiveMarketingEmail: "Yes"},
+	} {
+		var profile UserProfile
+		if err := json.Unmarshal([]byte(data), &profile); err != nil {
+			t.Errorf("%s: %v", data, err)
+			continue
+		}
+		if profile.Height != expected.Height || profile.Weight != expected.Weight || profile.ReceiveMarketingEmail != expected.ReceiveMarketingEmail {
+			t.Errorf("%s: expected %+v, got %+v", data, expected, profile)
+		}
+	}
+	var profile UserProfile
+	if err := json.Unmarshal([]byte(`{"height":"tall"}`), &profile); err == nil {
+		t.Error("expected a height which is no number to fail")
+	}
+}
+
+func TestUserProfileRoundTrip(t *testing.T) {
+	data := `{"givenName":"Jan","height":1.8,"receiveMarketingEmail":"Yes","preferences":{"unit":"metric"},"emailAddress":"jan@example.com"}`
+	var profile UserProfile
+	if err := json.Unmarshal([]byte(data), &profile); err != nil {
+		t.Fatal(err)
+	}
+	out, err := json.Marshal(profile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var in, got map[string]interface{}
+	json.Unmarshal([]byte(data), &in)
+	json.Unmarshal(out, &got)
+	if len(got) != len(in) || got["emailAddress"] != "jan@example.com" || got["height"] != 1.8 {
+		t.Errorf("expected %s, got %s", data, out)
+	}
+}
@@ -0,0 +1,93 @@
+package client
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/Jeffail/gabs/v2"
+)
+
+// FieldError describes a single field which failed DHP validation.
+// It is found in Response.Errors when DHP answers with 1254 Validation Errors
+type FieldError struct {
+	Field   string // The name of the offending field, empty when DHP did not tell
+	DhpCode int    // The DHP code of the failure, e.g. 1165
+	Message string // Human readable description of DhpCode
+}
+
+func (e *FieldError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("%d: %s", e.DhpCode, e.Message)
+	}
+	return fmt.Sprintf("%s: %d: %s", e.Field, e.DhpCode, e.Message)
+}
+
+// fieldErrorsPath is where a 1254 Validation Errors response lists the
+// failures, one object per field:
+//
+//	{"responseCode":"1254","exchange":{"validationErrors":[
+//		{"field":"loginId","code":"1165","message":"LoginId is required"}]}}
+//
+// Some assemblies send an object of field names to codes instead
+const fieldErrorsPath = "exchange.validationErrors"
+
+// parseFieldErrors extracts per-field validation failures from a DHP body,
+// in the order DHP lists them or sorted by field name
+func parseFieldErrors(jsonParsed *gabs.Container) []error {
+	var errs []error
+	container := jsonParsed.Path(fieldErrorsPath)
+	switch data := container.Data().(type) {
+	case []interface{}:
+		for _, child := range container.Children() {
+			if e := parseFieldError("", child); e != nil {
+				errs = append(errs, e)
+			}
+		}
+	case map[string]interface{}:
+		fields := make([]string, 0, len(data))
+		for field := range data {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+		for _, field := range fields {
+			if e := parseFieldError(field, container.Search(field)); e != nil {
+				errs = append(errs, e)
+			}
+		}
+	}
+	return errs
+}
+
+func parseFieldError(field string, entry *gabs.Container) *FieldError {
+	var code int
+	var message string
+	switch data := entry.Data().(type) {
+	case string:
+		code, _ = strconv.Atoi(data)
+	case float64:
+		code = int(data)
+	case map[string]interface{}:
+		if s, ok := data["field"].(string); ok && s != "" {
+			field = s
+		}
+		switch c := data["code"].(type) {
+		case string:
+			code, _ = strconv.Atoi(c)
+		case float64:
+			code = int(c)
+		}
+		message, _ = data["message"].(string)
+	}
+	if code == 0 && message == "" {
+		return nil
+	}
+	if _, known := apiError[code]; known || message == "" {
+		message = StatusCodeToString(code)
+	}
+	return &FieldError{
+		Field:   field,
+		DhpCode: code,
+		Message: message,
+	}
+}
@@ -0,0 +1,53 @@
+package client
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Jeffail/gabs/v2"
+)
+
+func fieldErrorsOf(t *testing.T, body string) []FieldError {
+	jsonParsed, err := gabs.ParseJSON([]byte(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fieldErrors []FieldError
+	for _, e := range parseFieldErrors(jsonParsed) {
+		fieldErrors = append(fieldErrors, *e.(*FieldError))
+	}
+	return fieldErrors
+}
+
+func TestParseFieldErrorsList(t *testing.T) {
+	body := `{"responseCode":"1254","exchange":{"validationErrors":[
+		{"field":"password","code":"1168","message":"Password is required."},
+		{"field":"loginId","code":1165}]}}`
+	expected := []FieldError{
+		{Field: "password", DhpCode: 1168, Message: "Password is required"},
+		{Field: "loginId", DhpCode: 1165, Message: "LoginId is required"},
+	}
+	if got := fieldErrorsOf(t, body); !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %v, expected %v", got, expected)
+	}
+}
+
+func TestParseFieldErrorsMapIsSorted(t *testing.T) {
+	body := `{"responseCode":"1254","exchange":{"validationErrors":{
+		"password":"1168","loginId":"1165","givenName":"1189","birthday":"1200"}}}`
+	for i := 0; i < 20; i++ {
+		var fields []string
+		for _, e := range fieldErrorsOf(t, body) {
+			fields = append(fields, e.Field)
+		}
+		if expected := []string{"birthday", "givenName", "loginId", "password"}; !reflect.DeepEqual(fields, expected) {
+			t.Fatalf("got %v, expected %v", fields, expected)
+		}
+	}
+}
+
+func TestParseFieldErrorsElsewhereIgnored(t *testing.T) {
+	if got := fieldErrorsOf(t, `{"responseCode":"1254","errors":["1165"]}`); len(got) != 0 {
+		t.Errorf("expected no field errors, got %v", got)
+	}
+}
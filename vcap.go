@@ -0,0 +1,152 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	SERVICE_AUTH         = "auth"
+	SERVICE_SUBSCRIPTION = "subscription"
+	SERVICE_CUSTOMERCARE = "customercare"
+	SERVICE_OBSERVATION  = "observation"
+
+	// SERVICE_BINDING_ENV names the environment variable holding
+	// the VCAP_SERVICES binding to read the configuration from
+	SERVICE_BINDING_ENV = "DHP_SERVICE_BINDING"
+)
+
+// serviceVariables names the settings of a DHP service
+type serviceVariables struct {
+	url           string
+	signingKey    string
+	signingSecret string
+}
+
+var serviceEnv = map[string]serviceVariables{
+	SERVICE_AUTH:         {"DHP_AUTH_URL", "DHP_SIGNING_KEY", "DHP_SIGNING_SECRET"},
+	SERVICE_SUBSCRIPTION: {"DHP_SUBSCRIPTION_SERVICE_URL", "DHP_SUBSCRIPTION_SIGNING_KEY", "DHP_SUBSCRIPTION_SIGNING_SECRET"},
+	SERVICE_CUSTOMERCARE: {"DHP_AUTH_URL", "DHP_CUSTOMERCARE_SIGNING_KEY", "DHP_CUSTOMERCARE_SIGNING_SECRET"},
+	SERVICE_OBSERVATION:  {"DHP_OBSERVATION_SERVICE_URL", "DHP_OBSERVATION_SIGNING_KEY", "DHP_OBSERVATION_SIGNING_SECRET"},
+}
+
+// VCAPService is a service instance bound to a Cloud Foundry app
+type VCAPService struct {
+	Name        string                 `json:"name"`
+	Label       string                 `json:"label"`
+	Tags        []string               `json:"tags"`
+	Credentials map[string]interface{} `json:"credentials"`
+}
+
+// Credential returns a string credential of the service, or "" when absent
+func (service VCAPService) Credential(key string) string {
+	value, _ := service.Credentials[key].(string)
+	return value
+}
+
+// setting returns the value of a DHP_* variable from the credentials of the
+// service. It is looked up as is and in lower case without the DHP_ prefix,
+// so both DHP_AUTH_URL and auth_url are understood
+func (service VCAPService) setting(variable string) string {
+	if value := service.Credential(variable); value != "" {
+		return value
+	}
+	return service.Credential(strings.ToLower(strings.TrimPrefix(variable, "DHP_")))
+}
+
+// VCAPServices is the parsed form of VCAP_SERVICES, keyed by service label
+type VCAPServices map[string][]VCAPService
+
+// LoadVCAPServices parses the VCAP_SERVICES environment variable
+func LoadVCAPServices() (VCAPServices, error) {
+	return parseVCAPServices(os.Getenv("VCAP_SERVICES"))
+}
+
+func parseVCAPServices(env string) (VCAPServices, error) {
+	if env == "" {
+		return nil, errors.New("VCAP_SERVICES is not set")
+	}
+	var services VCAPServices
+	if err := json.Unmarshal([]byte(env), &services); err != nil {
+		return nil, fmt.Errorf("parsing VCAP_SERVICES: %v", err)
+	}
+	return services, nil
+}
+
+// Find returns the bound service with the given name
+func (services VCAPServices) Find(name string) (VCAPService, bool) {
+	for _, instances := range services {
+		for _, service := range instances {
+			if service.Name == name {
+				return service, true
+			}
+		}
+	}
+	return VCAPService{}, false
+}
+
+// Lookup returns the bound service with the given name or, failing that,
+// the first one with the given label or tag
+func (services VCAPServices) Lookup(binding string) (VCAPService, bool) {
+	if service, ok := services.Find(binding); ok {
+		return service, true
+	}
+	if instances := services[binding]; len(instances) > 0 {
+		return instances[0], true
+	}
+	for _, instances := range services {
+		for _, service := range instances {
+			for _, tag := range service.Tags {
+				if tag == binding {
+					return service, true
+				}
+			}
+		}
+	}
+	return VCAPService{}, false
+}
+
+// LoadServiceConfig builds the configuration of one of the DHP services
+// SERVICE_AUTH, SERVICE_SUBSCRIPTION, SERVICE_CUSTOMERCARE or
+// SERVICE_OBSERVATION. Settings are read from the credentials of the
+// VCAP_SERVICES binding with the given name, label or tag, falling back
+// to the DHP_* environment variables.
+// Without a binding or outside Cloud Foundry only the environment is used.
+// It fails when neither provides the URL or signing keys of the service
+func LoadServiceConfig(service, binding string) (ApiClientConfig, error) {
+	variables, ok := serviceEnv[service]
+	if !ok {
+		return ApiClientConfig{}, fmt.Errorf("unknown DHP service %s", service)
+	}
+	var bound VCAPService
+	if binding != "" && os.Getenv("VCAP_SERVICES") != "" {
+		services, err := LoadVCAPServices()
+		if err != nil {
+			return ApiClientConfig{}, err
+		}
+		if bound, ok = services.Lookup(binding); !ok {
+			return ApiClientConfig{}, fmt.Errorf("service binding %s not found in VCAP_SERVICES", binding)
+		}
+	}
+	setting := func(variable string) string {
+		if value := bound.setting(variable); value != "" {
+			return value
+		}
+		return os.Getenv(variable)
+	}
+	config := ApiClientConfig{
+		ApiBaseUrl:         setting(variables.url),
+		DhpApplicationName: setting("DHP_APPLICATION_NAME"),
+		SigningKey:         setting(variables.signingKey),
+		SigningSecret:      setting(variables.signingSecret),
+		PropositionName:    setting("DHP_PROPOSITION_NAME"),
+	}
+	if config.ApiBaseUrl == "" && config.SigningKey == "" && config.SigningSecret == "" {
+		return config, fmt.Errorf("no configuration for DHP service %s, bind a service or set %s, %s and %s",
+			service, variables.url, variables.signingKey, variables.signingSecret)
+	}
+	return config, nil
+}
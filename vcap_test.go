@@ -0,0 +1,124 @@
+package client
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+const testVCAPBindings = `{
+	"user-provided": [
+		{"name": "dhp", "label": "user-provided", "tags": ["dhp-config"],
+		 "credentials": {"DHP_AUTH_URL": "https://auth", "signing_key": "key", "signing_secret": "secret", "application_name": "app"}}
+	],
+	"hsdp-observation": [
+		{"name": "observations", "label": "hsdp-observation", "tags": ["obs"],
+		 "credentials": {"observation_service_url": "https://obs", "observation_signing_key": "obs-key", "observation_signing_secret": "obs-secret"}}
+	]
+}`
+
+// clearServiceEnv unsets the DHP_* variables for the duration of a test
+func clearServiceEnv(t *testing.T) {
+	for _, variables := range serviceEnv {
+		for _, variable := range []string{variables.url, variables.signingKey, variables.signingSecret} {
+			t.Setenv(variable, "")
+			os.Unsetenv(variable)
+		}
+	}
+	for _, variable := range []string{"DHP_APPLICATION_NAME", "DHP_PROPOSITION_NAME", "VCAP_SERVICES"} {
+		t.Setenv(variable, "")
+		os.Unsetenv(variable)
+	}
+}
+
+func TestVCAPServicesLookup(t *testing.T) {
+	services, err := parseVCAPServices(testVCAPBindings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for binding, name := range map[string]string{
+		"dhp":              "dhp",
+		"observations":     "observations",
+		"user-provided":    "dhp",
+		"hsdp-observation": "observations",
+		"dhp-config":       "dhp",
+		"obs":              "observations",
+		"unknown":          "",
+	} {
+		service, ok := services.Lookup(binding)
+		if ok != (name != "") || service.Name != name {
+			t.Errorf("%s: expected %q, got %q", binding, name, service.Name)
+		}
+	}
+	if _, ok := services.Find("user-provided"); ok {
+		t.Error("expected Find to match names only")
+	}
+}
+
+func TestLoadServiceConfig(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		vcap     string
+		env      map[string]string
+		service  string
+		binding  string
+		expected ApiClientConfig
+		fails    bool
+	}{
+		{
+			name:     "binding",
+			vcap:     testVCAPBindings,
+			service:  SERVICE_AUTH,
+			binding:  "dhp-config",
+			expected: ApiClientConfig{ApiBaseUrl: "https://auth", DhpApplicationName: "app", SigningKey: "key", SigningSecret: "secret"},
+		},
+		{
+			name:     "binding with env fallback",
+			vcap:     testVCAPBindings,
+			env:      map[string]string{"DHP_APPLICATION_NAME": "app", "DHP_OBSERVATION_SIGNING_KEY": "ignored", "DHP_PROPOSITION_NAME": "prop"},
+			service:  SERVICE_OBSERVATION,
+			binding:  "obs",
+			expected: ApiClientConfig{ApiBaseUrl: "https://obs", DhpApplicationName: "app", SigningKey: "obs-key", SigningSecret: "obs-secret", PropositionName: "prop"},
+		},
+		{
+			name:     "env only",
+			env:      map[string]string{"DHP_SUBSCRIPTION_SERVICE_URL": "https://sub", "DHP_SUBSCRIPTION_SIGNING_KEY": "key", "DHP_SUBSCRIPTION_SIGNING_SECRET": "secret"},
+			service:  SERVICE_SUBSCRIPTION,
+			binding:  "dhp",
+			expected: ApiClientConfig{ApiBaseUrl: "https://sub", SigningKey: "key", SigningSecret: "secret"},
+		},
+		{
+			name:     "customer care keys",
+			env:      map[string]string{"DHP_AUTH_URL": "https://auth", "DHP_SIGNING_KEY": "app-key", "DHP_CUSTOMERCARE_SIGNING_KEY": "cc-key", "DHP_CUSTOMERCARE_SIGNING_SECRET": "cc-secret"},
+			service:  SERVICE_CUSTOMERCARE,
+			expected: ApiClientConfig{ApiBaseUrl: "https://auth", SigningKey: "cc-key", SigningSecret: "cc-secret"},
+		},
+		{name: "binding not found", vcap: testVCAPBindings, service: SERVICE_AUTH, binding: "other", fails: true},
+		{name: "malformed VCAP_SERVICES", vcap: "{", service: SERVICE_AUTH, binding: "dhp", fails: true},
+		{name: "unknown service", env: map[string]string{"DHP_AUTH_URL": "https://auth"}, service: "billing", fails: true},
+		{name: "neither binding nor env", service: SERVICE_AUTH, binding: "dhp", fails: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			clearServiceEnv(t)
+			if test.vcap != "" {
+				t.Setenv("VCAP_SERVICES", test.vcap)
+			}
+			for variable, value := range test.env {
+				t.Setenv(variable, value)
+			}
+			config, err := LoadServiceConfig(test.service, test.binding)
+			if test.fails {
+				if err == nil {
+					t.Errorf("expected an error, got %+v", config)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(config, test.expected) {
+				t.Errorf("expected %+v, got %+v", test.expected, config)
+			}
+		})
+	}
+}